@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/internal/report"
+	"github.com/urfave/cli/v3"
+)
+
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Compare two JSON result files and print the nodes that changed",
+		ArgsUsage: "<before.json> <after.json>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text or json",
+				Value:   "text",
+			},
+		},
+		Action: runDiff,
+	}
+}
+
+// runDiff compares two result files written with --format json. It fails
+// with exitFailure if any node is newly failing.
+func runDiff(_ context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 2 {
+		return &exitError{code: exitConfig, err: errors.New("diff takes two result files")}
+	}
+
+	format := cmd.String("format")
+	if format != "text" && format != "json" {
+		return &exitError{code: exitConfig, err: fmt.Errorf("unsupported format: %s", format)}
+	}
+
+	before, err := readReport(cmd.Args().Get(0))
+	if err != nil {
+		return &exitError{code: exitConfig, err: err}
+	}
+	after, err := readReport(cmd.Args().Get(1))
+	if err != nil {
+		return &exitError{code: exitConfig, err: err}
+	}
+
+	diff := report.Compare(before, after)
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diff)
+	default:
+		err = printDiff(os.Stdout, diff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if diff.NewlyFailing() {
+		return errors.New("nodes newly failing")
+	}
+
+	return nil
+}
+
+// readReport reads a result file. Files written before the schema was
+// versioned have the same format as version 1.
+func readReport(path string) (report.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report.Report{}, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	var r report.Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return report.Report{}, fmt.Errorf("failed to parse results %s: %w", path, err)
+	}
+	if r.SchemaVersion > report.SchemaVersion {
+		return report.Report{}, fmt.Errorf("unsupported schema version %d in %s", r.SchemaVersion, path)
+	}
+
+	return r, nil
+}
+
+// printDiff writes one line per node whose status changed, followed by the
+// block number and latency changes of the nodes checked in both runs
+func printDiff(w io.Writer, diff report.Diff) error {
+	changed := diff.Changed()
+	if len(changed) == 0 {
+		if _, err := fmt.Fprintln(w, "no status changes"); err != nil {
+			return err
+		}
+	}
+	for _, node := range changed {
+		if _, err := fmt.Fprintln(w, node.String()); err != nil {
+			return err
+		}
+	}
+
+	for _, node := range diff.Nodes {
+		if node.BlockAfter == 0 && node.BlockBefore == 0 {
+			continue
+		}
+
+		_, err := fmt.Fprintf(w, "node %s/%s: block %d -> %d (%+d), latency %s -> %s (%s)\n",
+			node.Chain, node.ID,
+			node.BlockBefore, node.BlockAfter, node.BlockDelta,
+			node.LatencyBefore.Round(time.Millisecond), node.LatencyAfter.Round(time.Millisecond),
+			formatDelta(node.LatencyDelta.Round(time.Millisecond)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatDelta formats a duration with an explicit sign
+func formatDelta(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDiffExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	result := filepath.Join(dir, "result.json")
+	if err := os.WriteFile(result, []byte(`{"schema_version": 1, "chain_results": [], "passed": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name string
+		args []string
+		code int // 0 = no error
+	}{
+		{name: "text", args: []string{result, result}},
+		{name: "json", args: []string{"--format", "json", result, result}},
+		{name: "unsupported format", args: []string{"--format", "csv", result, result}, code: exitConfig},
+		{name: "one file", args: []string{result}, code: exitConfig},
+		{name: "missing file", args: []string{result, missing}, code: exitConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := diffCommand().Run(context.Background(), append([]string{"diff"}, tt.args...))
+			if tt.code == 0 {
+				if err != nil {
+					t.Errorf("diff = %v, want nil", err)
+				}
+				return
+			}
+
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) || exitErr.code != tt.code {
+				t.Errorf("diff = %v, want exit code %d", err, tt.code)
+			}
+		})
+	}
+}
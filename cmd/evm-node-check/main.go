@@ -2,25 +2,81 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/sxwebdev/evm-node-check/internal/checker"
-	"github.com/sxwebdev/evm-node-check/internal/config"
+	"github.com/sxwebdev/evm-node-check/internal/notify"
+	"github.com/sxwebdev/evm-node-check/internal/store"
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
 	"github.com/urfave/cli/v3"
 )
 
+const notifyTimeout = 10 * time.Second
+
+// Exit codes, higher codes of check failures take precedence
+const (
+	exitFailure   = 1 // connection or other node failures
+	exitChainID   = 2 // chain ID mismatch
+	exitBlockHash = 3 // block hash divergence
+	exitConfig    = 4 // config load failure or invalid flags
+)
+
+// exitError is an error that terminates the process with a specific exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 func main() {
 	cmd := &cli.Command{
 		Name:  "evm-node-check",
 		Usage: "Check EVM RPC nodes for consistency",
 		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Path to YAML config file with nodes list, \"-\" for stdin or an http(s) URL (repeatable, configs are merged). Not required with --node",
+			},
+			&cli.StringSliceFlag{
+				Name:  "node",
+				Usage: "Check a node without a config as chain=url or chain=id=url (repeatable, merged with --config)",
+			},
+			&cli.BoolFlag{
+				Name:  "allow-duplicate-urls",
+				Usage: "Report duplicate connector URLs as warnings instead of failing to load the config",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "strict-connector-types",
+				Usage: "Fail to load the config if a connector has an unknown type instead of skipping it with a warning",
+				Value: false,
+			},
+			&cli.StringSliceFlag{
+				Name:  "chain",
+				Usage: "Only check the given chains (repeatable or comma-separated)",
+			},
 			&cli.StringFlag{
-				Name:     "config",
-				Aliases:  []string{"c"},
-				Usage:    "Path to YAML config file with nodes list",
-				Required: true,
+				Name:  "only-failed-from",
+				Usage: "Only check the chains that failed in this JSON result file of a previous run",
 			},
 			&cli.IntFlag{
 				Name:    "max-block-gap",
@@ -28,50 +84,552 @@ func main() {
 				Usage:   "Maximum allowed block gap between nodes",
 				Value:   10,
 			},
+			&cli.Uint64Flag{
+				Name:  "warn-block-gap",
+				Usage: "Block gap above which passing nodes are reported with a warning (0 = disabled)",
+				Value: 0,
+			},
+			&cli.StringSliceFlag{
+				Name:  "chain-gap",
+				Usage: "Maximum allowed block gap for a chain as chain=value (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "auto-gap",
+				Usage: "Raise the max block gap of each chain to the blocks produced in --auto-gap-window at its estimated block time",
+				Value: false,
+			},
+			&cli.DurationFlag{
+				Name:  "auto-gap-window",
+				Usage: "Time behind the chain head allowed with --auto-gap",
+				Value: 30 * time.Second,
+			},
+			&cli.StringSliceFlag{
+				Name:  "reference",
+				Usage: "Reference RPC endpoint of a chain as chain=url that all nodes are compared against (repeatable)",
+			},
 			&cli.IntFlag{
 				Name:    "block-hash-count",
 				Aliases: []string{"b"},
 				Usage:   "Number of recent blocks to compare hashes",
 				Value:   5,
 			},
+			&cli.StringFlag{
+				Name:  "head-source",
+				Usage: "Source of the head block number: block-number (eth_blockNumber) or latest-block (eth_getBlockByNumber latest)",
+				Value: checker.HeadSourceBlockNumber,
+			},
+			&cli.Uint64Flag{
+				Name:  "hash-confirmations",
+				Usage: "Compare block hashes starting this many blocks below head instead of at head",
+				Value: 0,
+			},
+			&cli.Uint64Flag{
+				Name:  "from-block",
+				Usage: "Compare block hashes from this block on instead of the last N blocks (requires --to-block)",
+			},
+			&cli.Uint64Flag{
+				Name:  "to-block",
+				Usage: "Compare block hashes up to and including this block (requires --from-block)",
+			},
+			&cli.BoolFlag{
+				Name:  "compare-headers",
+				Usage: "Report which header fields (parentHash, stateRoot) differ on block hash mismatches",
+			},
+			&cli.StringSliceFlag{
+				Name:  "hash-tags",
+				Usage: "Compare hashes of tagged blocks (finalized, safe, latest) instead of the last N blocks",
+			},
 			&cli.BoolFlag{
 				Name:    "skip-debug-check",
 				Aliases: []string{"s"},
 				Usage:   "Skip debug mode availability check",
 				Value:   false,
 			},
+			&cli.BoolFlag{
+				Name:  "debug-warn-only",
+				Usage: "Report nodes without debug mode as warnings instead of failures",
+				Value: false,
+			},
+			&cli.StringSliceFlag{
+				Name:  "debug-method",
+				Usage: "Tracing methods probed by the debug check, any one passing is enough (repeatable)",
+				Value: []string{"debug_traceBlockByNumber"},
+			},
+			&cli.Uint64Flag{
+				Name:  "debug-block-offset",
+				Usage: "Trace the block N blocks below head in the debug check",
+				Value: 0,
+			},
+			&cli.StringFlag{
+				Name:  "debug-tracer",
+				Usage: "Tracer passed to debug_* methods (empty for the default tracer)",
+				Value: "callTracer",
+			},
+			&cli.DurationFlag{
+				Name:  "debug-check-ttl",
+				Usage: "Reuse passed debug and archive checks of a node for this long when the checker runs repeatedly (0 = disabled)",
+				Value: 0,
+			},
+			&cli.Uint64Flag{
+				Name:  "min-peers",
+				Usage: "Minimum number of peers a node must have (0 = disabled)",
+				Value: 0,
+			},
+			&cli.FloatFlag{
+				Name:  "gas-price-tolerance",
+				Usage: "Maximum allowed gas price deviation from the chain median in percent (0 = disabled)",
+				Value: 0,
+			},
+			&cli.FloatFlag{
+				Name:  "base-fee-tolerance",
+				Usage: "Maximum allowed base fee deviation from the chain median in percent (0 = disabled)",
+				Value: 0,
+			},
+			&cli.FloatFlag{
+				Name:  "min-score",
+				Usage: "Minimum health score (0-100) a node must reach (0 = disabled)",
+				Value: 0,
+			},
+			&cli.IntFlag{
+				Name:  "max-tip-hashes",
+				Usage: "Maximum number of distinct block hashes allowed at the tip block (0 = disabled)",
+				Value: 0,
+			},
+			&cli.BoolFlag{
+				Name:  "check-net-version",
+				Usage: "Fail nodes whose net_version differs from their chain ID",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "check-genesis",
+				Usage: "Fail nodes whose genesis block hash differs from the other nodes of the chain",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "check-chain-name",
+				Usage: "Fail chains whose name is known but whose nodes report a different chain ID",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:  "chains-registry",
+				Usage: "YAML file mapping chain names to chain IDs, added to the built-in registry (implies --check-chain-name)",
+			},
+			&cli.BoolFlag{
+				Name:  "check-txpool",
+				Usage: "Check that nodes expose the transaction pool (txpool_status or txpool_content)",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "check-getlogs",
+				Usage: "Check that nodes serve eth_getLogs over the last --getlogs-range blocks",
+				Value: false,
+			},
+			&cli.Uint64Flag{
+				Name:  "getlogs-range",
+				Usage: "Number of blocks requested by the eth_getLogs check",
+				Value: 10,
+			},
+			&cli.BoolFlag{
+				Name:  "smoke-call",
+				Usage: "Check that nodes answer the eth_call configured under smoke-call for their chain",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "require-provider-diversity",
+				Usage: "Fail chains whose nodes are all served by one provider instead of warning",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:  "custom-check-cmd",
+				Usage: "Shell command run per node with NODE_URL, NODE_CHAIN and NODE_ID set; a non-zero exit fails the node",
+			},
+			&cli.DurationFlag{
+				Name:  "custom-check-timeout",
+				Usage: "Timeout of the custom check command",
+				Value: 30 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:  "reorg-check",
+				Usage: "Poll a block below the head twice and fail nodes whose hash changes",
+				Value: false,
+			},
+			&cli.Uint64Flag{
+				Name:  "reorg-depth",
+				Usage: "Number of blocks below the head polled by the reorg check",
+				Value: 2,
+			},
+			&cli.DurationFlag{
+				Name:  "reorg-delay",
+				Usage: "Delay between the two polls of the reorg check",
+				Value: 5 * time.Second,
+			},
+			&cli.BoolFlag{
+				Name:  "liveness-check",
+				Usage: "Read the head twice and fail nodes whose head doesn't advance",
+				Value: false,
+			},
+			&cli.DurationFlag{
+				Name:  "liveness-delay",
+				Usage: "Delay between the two head reads of the liveness check",
+				Value: 10 * time.Second,
+			},
+			&cli.Uint64Flag{
+				Name:  "min-head-advance",
+				Usage: "Minimum number of blocks the head must advance during the liveness delay",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "deep-block-check",
+				Usage: "Fetch the latest block with full transactions and verify its hash, transaction count and transactionsRoot",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:  "archive-check",
+				Usage: "Check that nodes retain historical state (archive nodes)",
+				Value: false,
+			},
+			&cli.Uint64Flag{
+				Name:  "archive-block",
+				Usage: "Old block height used by the archive check",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:  "tls-cert",
+				Usage: "Client certificate file for https connectors without their own tls settings",
+			},
+			&cli.StringFlag{
+				Name:  "tls-key",
+				Usage: "Client key file for --tls-cert",
+			},
+			&cli.StringFlag{
+				Name:  "tls-ca",
+				Usage: "CA bundle file added to the system roots for https connectors without their own tls settings",
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "Maximum number of nodes checked at the same time across all chains",
+				Value: 8,
+			},
+			&cli.BoolFlag{
+				Name:  "rate-limited-warn",
+				Usage: "Report nodes rate limited by their provider as warnings instead of failures",
+			},
+			&cli.IntFlag{
+				Name:  "dial-retries",
+				Usage: "Number of times a failed connection to a node is retried with backoff",
+				Value: 2,
+			},
+			&cli.FloatFlag{
+				Name:  "rate-limit",
+				Usage: "Maximum HTTP requests per second to all nodes together (0 = unlimited)",
+				Value: 0,
+			},
+			&cli.IntFlag{
+				Name:  "chain-parallelism",
+				Usage: "Number of chains checked at the same time",
+				Value: 4,
+			},
+			&cli.IntFlag{
+				Name:  "max-failed-per-chain",
+				Usage: "Pass chains with at most this many failed nodes, the failures are still reported (0 = any failed node fails the chain)",
+				Value: 0,
+			},
+			&cli.FloatFlag{
+				Name:  "min-healthy-ratio",
+				Usage: "Pass chains with at least this fraction of healthy nodes, e.g. 0.8 (0 = any failed node fails the chain)",
+				Value: 0,
+			},
+			&cli.DurationFlag{
+				Name:  "start-jitter",
+				Usage: "Delay the start of each node check by a random duration up to this value to spread out requests (0 = disabled)",
+				Value: 0,
+			},
+			&cli.BoolFlag{
+				Name:  "gzip",
+				Usage: "Request gzip-compressed responses from HTTP nodes, also if their headers set Accept-Encoding",
+				Value: false,
+			},
+			&cli.IntFlag{
+				Name:  "primary-exit-code",
+				Usage: "Exit code of runs in which a connector with priority primary failed, if higher than the code of the failures (0 = disabled)",
+				Value: 0,
+			},
+			&cli.BoolFlag{
+				Name:  "fail-fast",
+				Usage: "Stop checking after the first failure (results may be partial); validation failures only cancel other chains",
+				Value: false,
+			},
+			&cli.DurationFlag{
+				Name:  "max-runtime",
+				Usage: "Abort the check after this duration and report partial results (0 = no limit)",
+				Value: 0,
+			},
+			&cli.BoolFlag{
+				Name:  "allow-syncing",
+				Usage: "Do not fail nodes that report they are still syncing",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text, json or csv",
+				Value:   "text",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Write results to a file instead of stdout (replaced atomically)",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "Color the results table: auto (on a terminal unless NO_COLOR is set), always (also when writing to a file or pipe) or never",
+				Value: colorAuto,
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Never color the results table, same as --color=never",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:  "group-by",
+				Usage: "Group results by chain, or by upstream id within each chain: chain or id",
+				Value: "chain",
+			},
+			&cli.IntFlag{
+				Name:  "show-slowest",
+				Usage: "List the N slowest nodes of all chains in text and JSON output (0 = disabled)",
+				Value: 0,
+			},
+			&cli.StringFlag{
+				Name:  "webhook-url",
+				Usage: "URL to POST failed nodes to when failures are detected",
+			},
+			&cli.StringFlag{
+				Name:  "slack-webhook",
+				Usage: "Slack incoming webhook URL to send failures to",
+			},
+			&cli.StringFlag{
+				Name:  "telegram-token",
+				Usage: "Telegram bot token used to send failures (requires --telegram-chat)",
+			},
+			&cli.StringFlag{
+				Name:  "telegram-chat",
+				Usage: "Telegram chat ID to send failures to",
+			},
+			&cli.StringFlag{
+				Name:  "nats-url",
+				Usage: "NATS server URL to publish run results to",
+			},
+			&cli.StringFlag{
+				Name:  "nats-subject",
+				Usage: "NATS subject for published run results",
+				Value: "evm-node-check.results",
+			},
+			&cli.StringFlag{
+				Name:  "status-file",
+				Usage: "Write a compact JSON run status to this file after every run, whether it passed or not",
+			},
+			&cli.StringFlag{
+				Name:  "db",
+				Usage: "Append the result of every node to this SQLite database for trend analysis (requires a build with -tags sqlite)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Validate the config and exit without contacting any nodes",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "Log format: text or json (does not affect --format)",
+				Value: "text",
+			},
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"v"},
 				Usage:   "Enable verbose output",
 				Value:   false,
 			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "Only print failures, and nothing if all nodes pass",
+				Value:   false,
+			},
+		},
+		Commands: []*cli.Command{
+			diffCommand(),
 		},
 		Action: run,
 	}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
+	// Cancel the check on the first interrupt to print partial results, a
+	// second interrupt terminates immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := cmd.Run(ctx, os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+
+		code := exitFailure
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		}
+		os.Exit(code)
 	}
 }
 
-func run(ctx context.Context, cmd *cli.Command) error {
+func run(ctx context.Context, cmd *cli.Command) (err error) {
+	// Write the status file however the run ends
+	start := time.Now()
+	var result *checker.CheckResult
+	if statusPath := cmd.String("status-file"); statusPath != "" {
+		defer func() {
+			status := newRunStatus(start, cmd.StringSlice("config"), result, err)
+			if statusErr := writeStatusFile(statusPath, status); statusErr != nil {
+				err = errors.Join(err, statusErr)
+			}
+		}()
+	}
+
 	// Setup logger
 	logLevel := slog.LevelInfo
-	if cmd.Bool("verbose") {
+	quiet := cmd.Bool("quiet")
+	switch {
+	case quiet && cmd.Bool("verbose"):
+		return &exitError{code: exitConfig, err: errors.New("--quiet and --verbose can't be used together")}
+	case quiet:
+		logLevel = slog.LevelWarn
+	case cmd.Bool("verbose"):
 		logLevel = slog.LevelDebug
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	format := cmd.String("format")
+	if format != "text" && format != "json" && format != "csv" {
+		return &exitError{code: exitConfig, err: fmt.Errorf("unsupported format: %s", format)}
+	}
+
+	groupBy := cmd.String("group-by")
+	if groupBy != "chain" && groupBy != "id" {
+		return &exitError{code: exitConfig, err: fmt.Errorf("unsupported group by: %s", groupBy)}
+	}
+	groupByID := groupBy == "id"
+
+	colorMode := cmd.String("color")
+	if colorMode != colorAuto && colorMode != colorAlways && colorMode != colorNever {
+		return &exitError{code: exitConfig, err: fmt.Errorf("unsupported color mode: %s", colorMode)}
+	}
+	if cmd.Bool("no-color") {
+		colorMode = colorNever
+	}
+	table, color := textMode(colorMode, cmd.String("output"))
+
+	// Keep stdout clean for machine-readable output, and in quiet mode for
+	// the results, so that warnings of a passing run don't end up there
+	logOutput := os.Stdout
+	if quiet || (format != "text" && cmd.String("output") == "") {
+		logOutput = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{
 		Level: logLevel,
-	}))
+	}
+
+	var newLogHandler func(w io.Writer) slog.Handler
+	switch logFormat := cmd.String("log-format"); logFormat {
+	case "text":
+		newLogHandler = func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, handlerOpts) }
+	case "json":
+		newLogHandler = func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, handlerOpts) }
+	default:
+		return &exitError{code: exitConfig, err: fmt.Errorf("unsupported log format: %s", logFormat)}
+	}
+
+	logger := slog.New(newLogHandler(logOutput))
+
+	// Text results are logged to stdout, also if the logs go to stderr
+	resultLogger := logger
+	if format == "text" && logOutput != os.Stdout {
+		resultLogger = slog.New(newLogHandler(os.Stdout))
+	}
 
 	// Load config
-	configPath := cmd.String("config")
-	cfg, err := config.Load(configPath)
+	nodes, err := parseNodes(cmd.StringSlice("node"))
+	if err != nil {
+		return &exitError{code: exitConfig, err: err}
+	}
+	if len(cmd.StringSlice("config")) == 0 && len(nodes) == 0 {
+		return &exitError{code: exitConfig, err: errors.New("--config or --node is required")}
+	}
+
+	cfg, err := config.LoadFiles(cmd.StringSlice("config"), config.LoadOptions{
+		AllowDuplicateURLs:   cmd.Bool("allow-duplicate-urls"),
+		StrictConnectorTypes: cmd.Bool("strict-connector-types"),
+		Nodes:                nodes,
+	})
+	if err != nil {
+		return &exitError{code: exitConfig, err: fmt.Errorf("failed to load config: %w", err)}
+	}
+
+	for _, warning := range cfg.Warnings {
+		logger.Warn("config warning", "warning", warning)
+	}
+
+	chains := cmd.StringSlice("chain")
+
+	// Re-check only the chains that failed in a previous run, within the
+	// chains selected with --chain
+	if path := cmd.String("only-failed-from"); path != "" {
+		previous, err := readReport(path)
+		if err != nil {
+			return &exitError{code: exitConfig, err: err}
+		}
+
+		failedChains := previous.FailedChains()
+		if len(chains) > 0 {
+			failedChains = slices.DeleteFunc(failedChains, func(chain string) bool {
+				return !slices.Contains(chains, chain)
+			})
+		}
+		if len(failedChains) == 0 {
+			logger.Info("no failed chains in previous results, nothing to check", "file", path)
+			return nil
+		}
+
+		logger.Info("checking chains that failed in previous results", "file", path, "chains", failedChains)
+		chains = failedChains
+	}
+
+	if len(chains) > 0 {
+		if err := cfg.FilterChains(chains); err != nil {
+			return &exitError{code: exitConfig, err: err}
+		}
+	}
+
+	hashTags := cmd.StringSlice("hash-tags")
+	for _, tag := range hashTags {
+		if tag != "finalized" && tag != "safe" && tag != "latest" {
+			return &exitError{code: exitConfig, err: fmt.Errorf("unsupported hash tag: %s", tag)}
+		}
+	}
+
+	var hashRange *checker.BlockRange
+	if cmd.IsSet("from-block") || cmd.IsSet("to-block") {
+		if !cmd.IsSet("from-block") || !cmd.IsSet("to-block") {
+			return &exitError{code: exitConfig, err: errors.New("--from-block and --to-block must be set together")}
+		}
+		hashRange = &checker.BlockRange{
+			From: cmd.Uint64("from-block"),
+			To:   cmd.Uint64("to-block"),
+		}
+	}
+
+	chainGaps, err := parseChainGaps(cmd.StringSlice("chain-gap"))
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return &exitError{code: exitConfig, err: err}
 	}
 
 	nodesByChain := cfg.GetNodesByChain()
@@ -80,79 +638,314 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		totalNodes += len(nodes)
 	}
 
+	references, err := parseReferences(cmd.StringSlice("reference"))
+	if err != nil {
+		return &exitError{code: exitConfig, err: err}
+	}
+	for chain := range references {
+		if _, ok := nodesByChain[chain]; !ok {
+			return &exitError{code: exitConfig, err: fmt.Errorf("invalid reference: chain %q has no nodes", chain)}
+		}
+	}
+
 	logger.Info("loaded config", "chains", len(nodesByChain), "total_nodes", totalNodes)
 
+	chainRegistry := checker.DefaultChainRegistry()
+	if path := cmd.String("chains-registry"); path != "" {
+		custom, err := config.LoadChainRegistry(path)
+		if err != nil {
+			return &exitError{code: exitConfig, err: err}
+		}
+		maps.Copy(chainRegistry, custom)
+	}
+
+	var clientTLS *config.TLSConfig
+	if cert, key, ca := cmd.String("tls-cert"), cmd.String("tls-key"), cmd.String("tls-ca"); cert != "" || key != "" || ca != "" {
+		clientTLS = &config.TLSConfig{CertFile: cert, KeyFile: key, CAFile: ca}
+		if err := clientTLS.Validate(); err != nil {
+			return &exitError{code: exitConfig, err: fmt.Errorf("invalid --tls-cert, --tls-key and --tls-ca: %w", err)}
+		}
+	}
+
+	if cmd.Int("max-block-gap") < 0 {
+		return &exitError{code: exitConfig, err: fmt.Errorf("invalid max block gap: %d, must not be negative", cmd.Int("max-block-gap"))}
+	}
+
 	// Setup checker options
 	opts := checker.Options{
-		MaxBlockGap:    uint64(cmd.Int("max-block-gap")),
-		BlockHashCount: int(cmd.Int("block-hash-count")),
-		CheckDebugMode: !cmd.Bool("skip-debug-check"),
+		MaxBlockGap:       uint64(cmd.Int("max-block-gap")),
+		BlockHashCount:    int(cmd.Int("block-hash-count")),
+		CheckDebugMode:    !cmd.Bool("skip-debug-check"),
+		AllowSyncing:      cmd.Bool("allow-syncing"),
+		ChainParallelism:  int(cmd.Int("chain-parallelism")),
+		MaxTipHashes:      int(cmd.Int("max-tip-hashes")),
+		CheckArchive:      cmd.Bool("archive-check"),
+		ArchiveBlock:      cmd.Uint64("archive-block"),
+		HashTags:          hashTags,
+		MinPeers:          cmd.Uint64("min-peers"),
+		Concurrency:       int(cmd.Int("concurrency")),
+		GasPriceTolerance: cmd.Float("gas-price-tolerance"),
+		CheckTxPool:       cmd.Bool("check-txpool"),
+		ChainMaxBlockGap:  chainGaps,
+		CheckReorg:        cmd.Bool("reorg-check"),
+		ReorgDepth:        cmd.Uint64("reorg-depth"),
+		ReorgDelay:        cmd.Duration("reorg-delay"),
+		CheckLiveness:     cmd.Bool("liveness-check"),
+		LivenessDelay:     cmd.Duration("liveness-delay"),
+		MinHeadAdvance:    cmd.Uint64("min-head-advance"),
+		WarnBlockGap:      cmd.Uint64("warn-block-gap"),
+		DebugMethods:      cmd.StringSlice("debug-method"),
+		DebugTracer:       cmd.String("debug-tracer"),
+		DebugBlockOffset:  cmd.Uint64("debug-block-offset"),
+		ScoreWeights:      checker.DefaultScoreWeights(),
+		MinScore:          cmd.Float("min-score"),
+		CheckNetVersion:   cmd.Bool("check-net-version"),
+		CheckGenesis:      cmd.Bool("check-genesis"),
+		StartJitter:       cmd.Duration("start-jitter"),
+		HeadSource:        cmd.String("head-source"),
+		References:        references,
+		DebugWarnOnly:     cmd.Bool("debug-warn-only"),
+		FailFast:          cmd.Bool("fail-fast"),
+		BaseFeeTolerance:  cmd.Float("base-fee-tolerance"),
+		CheckChainName:    cmd.Bool("check-chain-name") || cmd.String("chains-registry") != "",
+		ChainRegistry:     chainRegistry,
+		DebugCheckTTL:     cmd.Duration("debug-check-ttl"),
+		ClientTLS:         clientTLS,
+		DeepBlockCheck:    cmd.Bool("deep-block-check"),
+		HashConfirmations: cmd.Uint64("hash-confirmations"),
+		CheckGetLogs:      cmd.Bool("check-getlogs"),
+		GetLogsRange:      cmd.Uint64("getlogs-range"),
+		RateLimit:         cmd.Float("rate-limit"),
+		DialRetries:       int(cmd.Int("dial-retries")),
+		CompareHeaders:    cmd.Bool("compare-headers"),
+		HashRange:         hashRange,
+		RateLimitedWarn:   cmd.Bool("rate-limited-warn"),
+		CheckSmokeCall:    cmd.Bool("smoke-call"),
+		CustomCmd:         cmd.String("custom-check-cmd"),
+		CustomCmdTimeout:  cmd.Duration("custom-check-timeout"),
+		RequireDiversity:  cmd.Bool("require-provider-diversity"),
+		MaxRuntime:        cmd.Duration("max-runtime"),
+		AutoGap:           cmd.Bool("auto-gap"),
+		AutoGapWindow:     cmd.Duration("auto-gap-window"),
+		Gzip:              cmd.Bool("gzip"),
+		MaxFailedPerChain: int(cmd.Int("max-failed-per-chain")),
+		MinHealthyRatio:   cmd.Float("min-healthy-ratio"),
+	}
+
+	if err := opts.Validate(); err != nil {
+		return &exitError{code: exitConfig, err: fmt.Errorf("invalid options: %w", err)}
+	}
+	for _, warning := range opts.Lint() {
+		logger.Warn("option warning", "warning", warning)
+	}
+
+	if cmd.Bool("dry-run") {
+		return dryRun(logger, cfg, nodesByChain)
+	}
+
+	// Setup notifiers
+	var notifiers []notify.Notifier
+	if webhookURL := cmd.String("webhook-url"); webhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhook(webhookURL))
+	}
+	if slackURL := cmd.String("slack-webhook"); slackURL != "" {
+		notifiers = append(notifiers, notify.NewSlack(slackURL))
+	}
+	if token, chat := cmd.String("telegram-token"), cmd.String("telegram-chat"); token != "" || chat != "" {
+		if token == "" || chat == "" {
+			return &exitError{code: exitConfig, err: errors.New("--telegram-token and --telegram-chat must be set together")}
+		}
+		notifiers = append(notifiers, notify.NewTelegram(token, chat))
+	}
+	if natsURL := cmd.String("nats-url"); natsURL != "" {
+		notifiers = append(notifiers, notify.NewNATS(natsURL, cmd.String("nats-subject")))
+	}
+
+	// Open the results database before checking so that a bad path
+	// doesn't waste a run
+	var db *store.Store
+	if path := cmd.String("db"); path != "" {
+		db, err = store.Open(ctx, path)
+		if err != nil {
+			return &exitError{code: exitConfig, err: fmt.Errorf("failed to open results database: %w", err)}
+		}
+		defer db.Close()
 	}
 
 	// Run checker
+	runAt := time.Now()
 	c := checker.New(cfg, opts, logger)
-	result, err := c.Check(ctx)
+	defer c.Close()
+	result, err = c.Check(ctx)
 	if err != nil {
 		return fmt.Errorf("check failed: %w", err)
 	}
 
-	// Print results
-	printResults(logger, result)
+	// Store results (failures are logged, they don't fail the run).
+	// Cancelled runs are not stored since their results are partial.
+	if db != nil && ctx.Err() == nil {
+		if err := db.Record(ctx, runAt, result); err != nil {
+			logger.Error("failed to store results", "error", err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		logger.Warn("check cancelled, results are partial")
+	}
+
+	// Send notifications (failures are logged, they don't fail the run).
+	// Cancelled runs are not notified since their results are partial.
+	if ctx.Err() == nil {
+		sendNotifications(ctx, logger, notifiers, result)
+	}
+
+	// Print results. In quiet mode there is nothing to print if all nodes
+	// passed, result files are written regardless.
+	if !quiet || hasFailures(result) || cmd.String("output") != "" {
+		if err := writeResults(resultLogger, logLevel, result, format, cmd.String("output"), table, color, int(cmd.Int("show-slowest")), groupByID); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+	}
 
 	if !result.Passed {
-		return fmt.Errorf("some nodes failed checks")
+		return &exitError{code: resultExitCode(result, int(cmd.Int("primary-exit-code"))), err: errors.New("some nodes failed checks")}
+	}
+
+	if len(result.FailedNodes) > 0 {
+		logger.Warn("some nodes failed checks, all chains have enough healthy nodes")
+		return nil
 	}
 
 	logger.Info("all nodes passed checks")
 	return nil
 }
 
-func printResults(logger *slog.Logger, result *checker.CheckResult) {
+// resultExitCode returns the exit code of the most severe failure in result.
+// Failures of primary nodes exit with primaryCode if it is set and higher.
+func resultExitCode(result *checker.CheckResult, primaryCode int) int {
+	code := exitFailure
+
+	categories := make([]checker.Category, 0, len(result.FailedNodes))
+	for _, fn := range result.FailedNodes {
+		categories = append(categories, fn.Code.Category())
+		if primaryCode > 0 && fn.Priority == config.PriorityPrimary {
+			code = max(code, primaryCode)
+		}
+	}
 	for _, chainResult := range result.ChainResults {
-		logger.Info("chain results",
-			"chain", chainResult.Chain,
-			"chain_id", chainResult.ExpectedChainID,
-			"max_block_number", chainResult.MaxBlockNumber,
-			"total_nodes", len(chainResult.Nodes),
-			"failed_nodes", len(chainResult.FailedNodes),
-		)
-
-		// Print successful nodes
-		for _, node := range chainResult.Nodes {
-			if node.Error != nil {
-				continue
-			}
+		for _, reason := range chainResult.Errors {
+			categories = append(categories, checker.Classify(reason))
+		}
+	}
 
-			// Check if this node is in failed list
-			failed := false
-			for _, fn := range chainResult.FailedNodes {
-				if fn.Address == node.Address {
-					failed = true
-					break
-				}
-			}
+	for _, category := range categories {
+		switch category {
+		case checker.CategoryChainID:
+			code = max(code, exitChainID)
+		case checker.CategoryBlockHash:
+			code = max(code, exitBlockHash)
+		}
+	}
 
-			if !failed {
-				logger.Info("node OK",
-					"id", node.ID,
-					"chain", node.Chain,
-					"block_number", node.BlockNumber,
-					"debug_ok", node.DebugOK,
-				)
-			}
+	return code
+}
+
+func sendNotifications(ctx context.Context, logger *slog.Logger, notifiers []notify.Notifier, result *checker.CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, result); err != nil {
+			logger.Warn("failed to send notification", "error", err)
 		}
 	}
+}
 
-	// Print failed nodes
-	if len(result.FailedNodes) > 0 {
-		logger.Warn("failed nodes detected")
-		for _, fn := range result.FailedNodes {
-			logger.Error("node FAILED",
-				"id", fn.ID,
-				"chain", fn.Chain,
-				"address", fn.Address,
-				"reason", fn.Reason,
-			)
+// parseChainGaps parses chain=value pairs into a map of block gaps per chain
+func parseChainGaps(values []string) (map[string]uint64, error) {
+	gaps := make(map[string]uint64, len(values))
+	for _, value := range values {
+		chain, gap, ok := strings.Cut(value, "=")
+		if !ok || chain == "" {
+			return nil, fmt.Errorf("invalid chain gap %q, expected chain=value", value)
 		}
+
+		n, err := strconv.ParseUint(gap, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain gap %q: %w", value, err)
+		}
+		gaps[chain] = n
 	}
+
+	return gaps, nil
+}
+
+// parseReferences parses chain=url pairs into a map of reference endpoints
+// per chain
+func parseReferences(values []string) (map[string]string, error) {
+	references := make(map[string]string, len(values))
+	for _, value := range values {
+		chain, url, ok := strings.Cut(value, "=")
+		if !ok || chain == "" || url == "" {
+			return nil, fmt.Errorf("invalid reference %q, expected chain=url", value)
+		}
+		if _, ok := references[chain]; ok {
+			return nil, fmt.Errorf("duplicate reference for chain %q", chain)
+		}
+		if err := config.ValidateURL(url); err != nil {
+			return nil, fmt.Errorf("invalid reference URL for chain %q: %w", chain, err)
+		}
+		references[chain] = url
+	}
+
+	return references, nil
+}
+
+// parseNodes parses --node values of the form chain=url or chain=id=url.
+// Nodes without an id are named after their chain and position.
+func parseNodes(values []string) ([]config.FlatNode, error) {
+	nodes := make([]config.FlatNode, 0, len(values))
+	for i, value := range values {
+		chain, url, ok := strings.Cut(value, "=")
+		if !ok || chain == "" || url == "" {
+			return nil, fmt.Errorf("invalid node %q, expected chain=url or chain=id=url", value)
+		}
+
+		id := fmt.Sprintf("%s-node-%d", chain, i+1)
+		if before, after, ok := strings.Cut(url, "="); ok && !strings.Contains(before, "://") {
+			id, url = before, after
+		}
+		if id == "" || url == "" {
+			return nil, fmt.Errorf("invalid node %q, expected chain=url or chain=id=url", value)
+		}
+
+		nodes = append(nodes, config.FlatNode{
+			ID:    id,
+			Chain: chain,
+			URL:   url,
+		})
+	}
+
+	return nodes, nil
+}
+
+// dryRun reports the nodes found in the config and any config problems
+// without performing network I/O
+func dryRun(logger *slog.Logger, cfg *config.Config, nodesByChain map[string][]config.NodeInfo) error {
+	for _, chain := range slices.Sorted(maps.Keys(nodesByChain)) {
+		logger.Info("chain config", "chain", chain, "nodes", len(nodesByChain[chain]))
+	}
+
+	problems := cfg.Lint()
+	for _, problem := range problems {
+		logger.Error("config problem", "problem", problem)
+	}
+
+	if len(problems) > 0 {
+		return &exitError{code: exitConfig, err: fmt.Errorf("config has %d problem(s)", len(problems))}
+	}
+
+	logger.Info("config is valid")
+
+	return nil
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseChainGaps(t *testing.T) {
+	gaps, err := parseChainGaps([]string{"ethereum=10", "arbitrum=200", "ethereum=20"})
+	if err != nil {
+		t.Fatalf("parseChainGaps: %v", err)
+	}
+	if want := map[string]uint64{"ethereum": 20, "arbitrum": 200}; !maps.Equal(gaps, want) {
+		t.Errorf("gaps = %v, want %v", gaps, want)
+	}
+
+	for _, value := range []string{"ethereum", "=10", "ethereum=", "ethereum=-1", "ethereum=ten"} {
+		if _, err := parseChainGaps([]string{value}); err == nil {
+			t.Errorf("parseChainGaps(%q) succeeded, want error", value)
+		}
+	}
+}
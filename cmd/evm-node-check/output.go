@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sxwebdev/evm-node-check/internal/report"
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// writeResults renders result in the given format to stdout, or to
+// outputPath if set. Text results are rendered as a table if table is set,
+// colored if color is set, see textMode. In quiet mode, i.e. above info
+// logLevel, the table only lists failures. Otherwise text results written to
+// a file use a separate logger so that operational logs don't end up in the
+// file. The showSlowest slowest nodes are listed in text and JSON output.
+// With groupByID, text and JSON output summarize the nodes of each chain per
+// upstream id.
+func writeResults(logger *slog.Logger, logLevel slog.Level, result *checker.CheckResult, format, outputPath string, table, color bool, showSlowest int, groupByID bool) error {
+	var buf bytes.Buffer
+
+	var w io.Writer = os.Stdout
+	if outputPath != "" {
+		w = &buf
+	}
+
+	var err error
+	switch format {
+	case "json":
+		err = printJSON(w, result, showSlowest, groupByID)
+	case "csv":
+		err = printCSV(w, result)
+	default:
+		if table {
+			// The table leaves out what the logs wouldn't show at logLevel
+			err = printTable(w, result, showSlowest, groupByID, color, logLevel > slog.LevelInfo)
+			break
+		}
+		if outputPath != "" {
+			logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+				Level: logLevel,
+			}))
+		}
+		printResults(logger, result, showSlowest, groupByID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		return nil
+	}
+
+	return writeFileAtomic(outputPath, buf.Bytes())
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and
+// renames it to path, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+func printResults(logger *slog.Logger, result *checker.CheckResult, showSlowest int, groupByID bool) {
+	for _, chainResult := range result.ChainResults {
+		logger.Info("chain results",
+			"chain", chainResult.Chain,
+			"chain_id", chainResult.ExpectedChainID,
+			"max_block_number", chainResult.MaxBlockNumber,
+			"total_nodes", len(chainResult.Nodes),
+			"failed_nodes", len(chainResult.FailedNodes),
+			"health_score", formatScore(chainResult.Health),
+		)
+
+		for _, reason := range chainResult.Errors {
+			logger.Error("chain FAILED",
+				"chain", chainResult.Chain,
+				"reason", reason,
+			)
+		}
+		for _, warning := range chainResult.Warnings {
+			logger.Warn("chain warning",
+				"chain", chainResult.Chain,
+				"warning", warning,
+			)
+		}
+
+		if groupByID {
+			for _, upstream := range chainResult.Upstreams() {
+				logger.Info("upstream results",
+					"chain", chainResult.Chain,
+					"id", upstream.ID,
+					"ok", fmt.Sprintf("%d/%d", upstream.PassedNodes, upstream.TotalNodes),
+				)
+			}
+		}
+
+		// Print blocks where nodes disagreed on the hash
+		printDivergences(logger, chainResult.Chain, chainResult.HashConsensus)
+		for _, tag := range slices.Sorted(maps.Keys(chainResult.TagHashConsensus)) {
+			printDivergences(logger.With("tag", tag), chainResult.Chain, chainResult.TagHashConsensus[tag])
+		}
+
+		// Print successful nodes
+		for _, node := range chainResult.Nodes {
+			if node.Error != nil {
+				continue
+			}
+
+			// Check if this node is in failed list
+			failed := false
+			for _, fn := range chainResult.FailedNodes {
+				if fn.Address == node.Address {
+					failed = true
+					break
+				}
+			}
+
+			if !failed {
+				logger.Info("node OK",
+					"id", node.ID,
+					"chain", node.Chain,
+					"block_number", node.BlockNumber,
+					"block_offset", node.BlockOffset,
+					"debug_ok", node.DebugOK,
+					"syncing", node.Syncing,
+					"peer_count", formatPeerCount(node.PeerCount),
+					"client", formatClientVersion(node.ClientVersion),
+					"health_score", formatScore(node.Health),
+				)
+			}
+
+			for _, warning := range node.Warnings {
+				logger.Warn("node warning",
+					"id", node.ID,
+					"chain", node.Chain,
+					"warning", warning,
+				)
+			}
+
+			logger.Debug("node timings",
+				"id", node.ID,
+				"chain", node.Chain,
+				"dial", node.Timings.Dial,
+				"chain_id", node.Timings.ChainID,
+				"genesis", node.Timings.Genesis,
+				"block_number", node.Timings.BlockNumber,
+				"sync_status", node.Timings.SyncStatus,
+				"peer_count", node.Timings.PeerCount,
+				"net_version", node.Timings.NetVersion,
+				"client_version", node.Timings.ClientVersion,
+				"gas_price", node.Timings.GasPrice,
+				"base_fee", node.Timings.BaseFee,
+				"blocks", node.Timings.Blocks,
+				"debug", node.Timings.Debug,
+				"archive", node.Timings.Archive,
+				"deep_block", node.Timings.DeepBlock,
+				"reorg", node.Timings.Reorg,
+				"liveness", node.Timings.Liveness,
+				"txpool", node.Timings.TxPool,
+				"get_logs", node.Timings.GetLogs,
+				"smoke_call", node.Timings.SmokeCall,
+				"custom_check", node.Timings.CustomCheck,
+				"total", node.Timings.Total,
+			)
+		}
+	}
+
+	// Print failed nodes
+	if len(result.FailedNodes) > 0 {
+		logger.Warn("failed nodes detected")
+		for _, fn := range result.FailedNodes {
+			msg := "node FAILED"
+			if fn.Priority == config.PriorityPrimary {
+				msg = "primary node FAILED"
+			}
+			logger.Error(msg,
+				"id", fn.ID,
+				"chain", fn.Chain,
+				"address", config.RedactURL(fn.Address),
+				"code", fn.Code,
+				"reason", fn.Reason,
+			)
+		}
+	}
+
+	// Print the slowest nodes of all chains
+	for _, node := range result.SlowestNodes(showSlowest) {
+		logger.Info("slow node",
+			"id", node.ID,
+			"chain", node.Chain,
+			"total", node.Total,
+		)
+	}
+
+	printSummary(logger, result)
+}
+
+// hasFailures reports whether any node or chain of result failed
+func hasFailures(result *checker.CheckResult) bool {
+	if len(result.FailedNodes) > 0 {
+		return true
+	}
+	for _, chainResult := range result.ChainResults {
+		if len(chainResult.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printSummary logs the summary of result at warn level if nodes or chains
+// failed, so that it is printed in quiet mode too
+func printSummary(logger *slog.Logger, result *checker.CheckResult) {
+	summary := result.Summary()
+	failures := make([]any, 0, len(summary.Failures))
+	for _, category := range checker.Categories() {
+		if count := summary.Failures[category.String()]; count > 0 {
+			failures = append(failures, slog.Int(category.String(), count))
+		}
+	}
+
+	level := slog.LevelInfo
+	if !result.Passed || hasFailures(result) {
+		level = slog.LevelWarn
+	}
+
+	logger.Log(context.Background(), level, "summary",
+		"chains", summary.TotalChains,
+		"nodes", summary.TotalNodes,
+		"passed", summary.PassedNodes,
+		"failed", summary.FailedNodes,
+		"warned", summary.WarnedNodes,
+		"rate_limited", summary.RateLimited,
+		slog.Group("failures", failures...),
+	)
+}
+
+// formatPeerCount returns the peer count or "unknown" if the node does not expose it
+func formatPeerCount(peerCount *uint64) string {
+	if peerCount == nil {
+		return "unknown"
+	}
+	return strconv.FormatUint(*peerCount, 10)
+}
+
+// formatClientVersion returns the client version or "unknown" if the node does not report it
+func formatClientVersion(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
+
+// formatScore formats a health score with one decimal place
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
+// printJSON writes result in the versioned format of the report package
+func printJSON(w io.Writer, result *checker.CheckResult, showSlowest int, groupByID bool) error {
+	r := report.New(result)
+	if groupByID {
+		r.Upstreams = report.NewUpstreams(result.Upstreams())
+	}
+	r.SlowestNodes = report.NewSlowestNodes(result.SlowestNodes(showSlowest))
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+var csvHeader = []string{
+	"chain", "id", "address", "chain_id", "block_number", "block_gap",
+	"block_offset", "debug_ok", "latency", "health_score", "status", "reason",
+}
+
+// nodeStatus returns "ok", "warning" or "failed" for a node with its
+// failure reasons, or its warnings if it passed with warnings
+func nodeStatus(chainResult checker.ChainResult, node checker.NodeResult) (string, []string) {
+	var reasons []string
+	for _, fn := range chainResult.FailedNodes {
+		if fn.Address == node.Address {
+			reasons = append(reasons, fn.Reason)
+		}
+	}
+
+	switch {
+	case len(reasons) > 0:
+		return "failed", reasons
+	case len(node.Warnings) > 0:
+		return "warning", node.Warnings
+	default:
+		return "ok", nil
+	}
+}
+
+// printCSV writes one row per node. Multiple failure reasons of a node are
+// joined with "; " and the reason is empty for passing nodes.
+func printCSV(out io.Writer, result *checker.CheckResult) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, chainResult := range result.ChainResults {
+		for _, node := range chainResult.Nodes {
+			status, reasons := nodeStatus(chainResult, node)
+
+			var chainID, blockNumber, blockGap, blockOffset string
+			if node.Error == nil {
+				chainID = node.ChainID.String()
+				blockNumber = strconv.FormatUint(node.BlockNumber, 10)
+				blockGap = strconv.FormatUint(node.BlockGap, 10)
+				blockOffset = strconv.FormatInt(node.BlockOffset, 10)
+			}
+
+			if err := w.Write([]string{
+				node.Chain,
+				node.ID,
+				config.RedactURL(node.Address),
+				chainID,
+				blockNumber,
+				blockGap,
+				blockOffset,
+				strconv.FormatBool(node.DebugOK),
+				node.Timings.Total.String(),
+				formatScore(node.Health),
+				status,
+				strings.Join(reasons, "; "),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// printDivergences logs the blocks of consensus that nodes disagreed on
+func printDivergences(logger *slog.Logger, chain string, consensus map[uint64]checker.HashConsensusInfo) {
+	for _, blockNum := range slices.Sorted(maps.Keys(consensus)) {
+		info := consensus[blockNum]
+		if len(info.Dissenting) == 0 {
+			continue
+		}
+		majorityHash := "none"
+		if info.MajorityHash != nil {
+			majorityHash = info.MajorityHash.Hex()
+		}
+		logger.Warn("block hash divergence",
+			"chain", chain,
+			"block", blockNum,
+			"majority_hash", majorityHash,
+			"majority_votes", info.MajorityVotes,
+			"dissenting", formatDissenting(info.Dissenting),
+		)
+	}
+}
+
+// formatDissenting formats dissenting hash groups as hash=[id id] pairs
+func formatDissenting(dissenting map[common.Hash][]string) string {
+	groups := make([]string, 0, len(dissenting))
+	for hash, nodeIDs := range dissenting {
+		groups = append(groups, fmt.Sprintf("%s=%v", hash.Hex(), nodeIDs))
+	}
+	slices.Sort(groups)
+
+	return strings.Join(groups, " ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+func TestPrintSummaryQuiet(t *testing.T) {
+	failed := checker.FailedNode{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Code: checker.ReasonBlockGap, Reason: "block gap too large"}
+	node := checker.NodeResult{ID: "a", Chain: "ethereum", Address: "https://a.example.com"}
+
+	tests := []struct {
+		name    string
+		result  *checker.CheckResult
+		printed bool
+	}{
+		{
+			name: "passed",
+			result: &checker.CheckResult{
+				ChainResults: []checker.ChainResult{{Chain: "ethereum", Nodes: []checker.NodeResult{node}, Passed: true}},
+				Passed:       true,
+			},
+		},
+		{
+			name: "failed node",
+			result: &checker.CheckResult{
+				ChainResults: []checker.ChainResult{{Chain: "ethereum", Nodes: []checker.NodeResult{node}, FailedNodes: []checker.FailedNode{failed}}},
+				FailedNodes:  []checker.FailedNode{failed},
+			},
+			printed: true,
+		},
+		{
+			name: "chain error only",
+			result: &checker.CheckResult{
+				ChainResults: []checker.ChainResult{{Chain: "ethereum", Nodes: []checker.NodeResult{node}, Errors: []string{"genesis hash split"}}},
+			},
+			printed: true,
+		},
+		{
+			name: "failed chain without errors",
+			result: &checker.CheckResult{
+				ChainResults: []checker.ChainResult{{Chain: "ethereum", Nodes: []checker.NodeResult{node}}},
+			},
+			printed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+			printSummary(logger, tt.result)
+			if got := strings.Contains(buf.String(), "msg=summary"); got != tt.printed {
+				t.Errorf("summary printed in quiet mode = %t, want %t: %s", got, tt.printed, buf.String())
+			}
+		})
+	}
+}
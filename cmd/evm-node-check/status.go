@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// runStatus is the compact run outcome written to --status-file
+type runStatus struct {
+	Time     time.Time        `json:"time"`
+	Config   []string         `json:"config"`
+	Duration float64          `json:"duration_seconds"`
+	Passed   bool             `json:"passed"`
+	ExitCode int              `json:"exit_code"`
+	Error    string           `json:"error,omitempty"`
+	Summary  *checker.Summary `json:"summary,omitempty"`
+	Chains   []chainStatus    `json:"chains,omitempty"`
+}
+
+type chainStatus struct {
+	Chain       string `json:"chain"`
+	Passed      bool   `json:"passed"`
+	TotalNodes  int    `json:"total_nodes"`
+	FailedNodes int    `json:"failed_nodes"`
+}
+
+// newRunStatus describes a run that started at start and ended with err.
+// result is nil if the run ended before the nodes were checked.
+func newRunStatus(start time.Time, configPaths []string, result *checker.CheckResult, err error) runStatus {
+	status := runStatus{
+		Time:     time.Now().UTC(),
+		Duration: time.Since(start).Seconds(),
+		Passed:   err == nil,
+	}
+
+	for _, path := range configPaths {
+		status.Config = append(status.Config, config.RedactURL(path))
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+		for _, path := range configPaths {
+			status.Error = config.RedactSecrets(status.Error, path)
+		}
+		status.ExitCode = exitFailure
+
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			status.ExitCode = exitErr.code
+		}
+	}
+
+	if result == nil {
+		return status
+	}
+
+	summary := result.Summary()
+	status.Summary = &summary
+
+	for _, chainResult := range result.ChainResults {
+		failed := make(map[string]bool)
+		for _, fn := range chainResult.FailedNodes {
+			failed[fn.Address] = true
+		}
+
+		status.Chains = append(status.Chains, chainStatus{
+			Chain:       chainResult.Chain,
+			Passed:      chainResult.Passed,
+			TotalNodes:  len(chainResult.Nodes),
+			FailedNodes: len(failed),
+		})
+	}
+
+	return status
+}
+
+// writeStatusFile atomically replaces path with status as JSON
+func writeStatusFile(path string, status runStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write status file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"golang.org/x/term"
+)
+
+// ANSI escape codes used for the status column. tabwriter counts escape
+// codes as text, so all codes have the same length to keep columns aligned.
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[01m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// useTable reports whether text results should be rendered as tables, which
+// is the case when they go to a terminal
+func useTable(outputPath string) bool {
+	return outputPath == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Values of --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// textMode decides how text results are rendered for the given --color
+// mode. Results are rendered as a table when they go to a terminal or when
+// color is forced with "always", which also colors tables written to a file
+// or a pipe. In "auto" mode the table is colored unless NO_COLOR is set, see
+// https://no-color.org.
+func textMode(colorMode, outputPath string) (table, color bool) {
+	switch colorMode {
+	case colorAlways:
+		return true, true
+	case colorNever:
+		return useTable(outputPath), false
+	default:
+		table = useTable(outputPath)
+		return table, table && os.Getenv("NO_COLOR") == ""
+	}
+}
+
+// printTable renders an aligned table of the nodes of each chain followed by
+// the chain errors, the slowest nodes and the summary. With groupByID the
+// nodes are ordered by upstream id and followed by a count per upstream.
+// With quiet, like the quiet log output, only failed and warned nodes, chain
+// errors and warnings and the summary are printed.
+func printTable(out io.Writer, result *checker.CheckResult, showSlowest int, groupByID, color, quiet bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	for _, chainResult := range result.ChainResults {
+		nodes := chainResult.Nodes
+		if quiet {
+			nodes = slices.DeleteFunc(slices.Clone(nodes), func(node checker.NodeResult) bool {
+				status, _ := nodeStatus(chainResult, node)
+				return status == "ok"
+			})
+			if len(nodes) == 0 && len(chainResult.Errors) == 0 && len(chainResult.Warnings) == 0 {
+				continue
+			}
+		}
+		if groupByID {
+			nodes = slices.Clone(nodes)
+			slices.SortStableFunc(nodes, func(a, b checker.NodeResult) int {
+				return strings.Compare(a.ID, b.ID)
+			})
+		}
+
+		if quiet {
+			fmt.Fprintln(w, chainResult.Chain)
+		} else {
+			fmt.Fprintf(w, "%s  chain ID %v  head %d  health %s\n",
+				chainResult.Chain, chainResult.ExpectedChainID, chainResult.MaxBlockNumber, formatScore(chainResult.Health))
+		}
+		if len(nodes) > 0 {
+			fmt.Fprintf(w, "  %s\tID\tBLOCK\tGAP\tPEERS\tDEBUG\tCLIENT\tTIME\tSCORE\tREASON\n", colorize("STATUS", colorBold, color))
+		}
+
+		for _, node := range nodes {
+			status, reasons := nodeStatus(chainResult, node)
+
+			var blockNumber, blockGap string
+			if node.Error == nil {
+				blockNumber = strconv.FormatUint(node.BlockNumber, 10)
+				blockGap = strconv.FormatUint(node.BlockGap, 10)
+			}
+
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\n",
+				formatStatus(status, color),
+				node.ID,
+				blockNumber,
+				blockGap,
+				formatPeerCount(node.PeerCount),
+				node.DebugOK,
+				formatClientVersion(node.ClientName),
+				node.Timings.Total.Round(time.Millisecond),
+				formatScore(node.Health),
+				strings.Join(reasons, "; "),
+			)
+		}
+
+		for _, reason := range chainResult.Errors {
+			fmt.Fprintf(w, "  %s\t%s\n", formatStatus("failed", color), reason)
+		}
+		for _, warning := range chainResult.Warnings {
+			fmt.Fprintf(w, "  %s\t%s\n", formatStatus("warning", color), warning)
+		}
+
+		if groupByID && !quiet {
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "  %s\tUPSTREAM\tOK\n", colorize("STATUS", colorBold, color))
+			for _, upstream := range chainResult.Upstreams() {
+				fmt.Fprintf(w, "  %s\t%s\t%d/%d\n",
+					formatStatus(upstreamStatus(upstream), color),
+					upstream.ID,
+					upstream.PassedNodes,
+					upstream.TotalNodes,
+				)
+			}
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if slowest := result.SlowestNodes(showSlowest); len(slowest) > 0 && !quiet {
+		fmt.Fprintln(w, "SLOWEST\tCHAIN\tTIME")
+		for _, node := range slowest {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", node.ID, node.Chain, node.Total.Round(time.Millisecond))
+		}
+		fmt.Fprintln(w)
+	}
+
+	summary := result.Summary()
+	fmt.Fprintf(w, "%d chains, %d nodes: %d passed, %d failed, %d warned\n",
+		summary.TotalChains, summary.TotalNodes, summary.PassedNodes, summary.FailedNodes, summary.WarnedNodes)
+
+	return w.Flush()
+}
+
+// formatStatus renders a node status as PASS, WARN or FAIL
+func formatStatus(status string, color bool) string {
+	var label, code string
+	switch status {
+	case "failed":
+		label, code = "FAIL", colorRed
+	case "warning":
+		label, code = "WARN", colorYellow
+	default:
+		label, code = "PASS", colorGreen
+	}
+
+	return colorize(label, code, color)
+}
+
+// upstreamStatus returns "ok" if all nodes of the upstream passed, "failed"
+// if none did and "warning" otherwise
+func upstreamStatus(upstream checker.UpstreamSummary) string {
+	switch {
+	case upstream.FailedNodes == 0:
+		return "ok"
+	case upstream.PassedNodes == 0:
+		return "failed"
+	default:
+		return "warning"
+	}
+}
+
+func colorize(s, code string, color bool) string {
+	if !color {
+		return s
+	}
+	return code + s + colorReset
+}
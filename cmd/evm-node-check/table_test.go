@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+func TestTextMode(t *testing.T) {
+	// Tests don't run on a terminal, so auto mode falls back to log lines
+	tests := []struct {
+		name       string
+		colorMode  string
+		outputPath string
+		noColor    string
+		table      bool
+		color      bool
+	}{
+		{name: "auto", colorMode: colorAuto},
+		{name: "auto to file", colorMode: colorAuto, outputPath: "results.txt"},
+		{name: "never", colorMode: colorNever},
+		{name: "always", colorMode: colorAlways, table: true, color: true},
+		{name: "always to file", colorMode: colorAlways, outputPath: "results.txt", table: true, color: true},
+		{name: "always overrides NO_COLOR", colorMode: colorAlways, noColor: "1", table: true, color: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			table, color := textMode(tt.colorMode, tt.outputPath)
+			if table != tt.table || color != tt.color {
+				t.Errorf("textMode(%q, %q) = %t, %t, want %t, %t", tt.colorMode, tt.outputPath, table, color, tt.table, tt.color)
+			}
+		})
+	}
+}
+
+func TestPrintTableColor(t *testing.T) {
+	result := &checker.CheckResult{
+		ChainResults: []checker.ChainResult{{
+			Chain:  "ethereum",
+			Nodes:  []checker.NodeResult{{ID: "a", Chain: "ethereum", Address: "https://a.example.com"}},
+			Passed: true,
+		}},
+		Passed: true,
+	}
+
+	for _, color := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := printTable(&buf, result, 0, false, color, false); err != nil {
+			t.Fatalf("printTable: %v", err)
+		}
+		if got := strings.Contains(buf.String(), "\x1b["); got != color {
+			t.Errorf("printTable(color=%t) wrote escape codes: %t", color, got)
+		}
+	}
+}
+
+func TestPrintTableQuiet(t *testing.T) {
+	failed := checker.FailedNode{ID: "b", Chain: "ethereum", Address: "https://b.example.com", Code: checker.ReasonBlockGap, Reason: "block gap too large"}
+	result := &checker.CheckResult{
+		ChainResults: []checker.ChainResult{
+			{
+				Chain: "ethereum",
+				Nodes: []checker.NodeResult{
+					{ID: "a", Chain: "ethereum", Address: "https://a.example.com"},
+					{ID: "b", Chain: "ethereum", Address: "https://b.example.com"},
+					{ID: "c", Chain: "ethereum", Address: "https://c.example.com", Warnings: []string{"fallback node failed"}},
+				},
+				FailedNodes: []checker.FailedNode{failed},
+			},
+			{
+				Chain:  "polygon",
+				Nodes:  []checker.NodeResult{{ID: "d", Chain: "polygon", Address: "https://d.example.com"}},
+				Passed: true,
+			},
+		},
+		FailedNodes: []checker.FailedNode{failed},
+	}
+
+	var buf bytes.Buffer
+	if err := printTable(&buf, result, 5, true, false, true); err != nil {
+		t.Fatalf("printTable: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"FAIL    b", "WARN    c", "block gap too large", "4 nodes: 3 passed, 1 failed, 1 warned"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("quiet table doesn't contain %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"PASS", "polygon", "chain ID", "SLOWEST", "UPSTREAM"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("quiet table contains %q:\n%s", unwanted, out)
+		}
+	}
+}
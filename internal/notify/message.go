@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+const (
+	// maxMessageFailures limits the failures listed in chat messages to stay
+	// below message size limits (4096 characters for Telegram)
+	maxMessageFailures = 20
+
+	// maxMessageReason truncates long failure reasons such as connection errors
+	maxMessageReason = 150
+)
+
+// formatMessage renders failures as a plain text chat message with counts
+// and up to maxFailures failures. Chain errors are listed before failed nodes.
+func formatMessage(result *checker.CheckResult, maxFailures int) string {
+	summary := result.Summary()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "evm-node-check: %d of %d nodes failed in %d chains\n",
+		summary.FailedNodes, summary.TotalNodes, summary.TotalChains)
+
+	var lines []string
+	for _, chainResult := range result.ChainResults {
+		for _, reason := range chainResult.Errors {
+			lines = append(lines, fmt.Sprintf("• %s: %s", chainResult.Chain, truncate(reason, maxMessageReason)))
+		}
+	}
+	for _, fn := range result.FailedNodes {
+		lines = append(lines, fmt.Sprintf("• %s/%s: %s", fn.Chain, fn.ID, truncate(fn.Reason, maxMessageReason)))
+	}
+
+	for i, line := range lines {
+		if i == maxFailures {
+			fmt.Fprintf(&b, "… and %d more\n", len(lines)-maxFailures)
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+const natsConnectTimeout = 5 * time.Second
+
+// NATS publishes an Event per run to a NATS subject
+type NATS struct {
+	url     string
+	subject string
+}
+
+func NewNATS(url, subject string) *NATS {
+	return &NATS{
+		url:     url,
+		subject: subject,
+	}
+}
+
+func (n *NATS) Notify(ctx context.Context, result *checker.CheckResult) error {
+	payload, err := json.Marshal(NewEvent(result))
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	nc, err := nats.Connect(n.url,
+		nats.Name("evm-node-check"),
+		nats.Timeout(natsConnectTimeout),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	defer nc.Close()
+
+	if err := nc.Publish(n.subject, payload); err != nil {
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+
+	if err := nc.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush nats connection: %w", err)
+	}
+
+	return nil
+}
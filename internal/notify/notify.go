@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+// Notifier publishes check results to an external system
+type Notifier interface {
+	Notify(ctx context.Context, result *checker.CheckResult) error
+}
+
+// Event is the JSON payload published for each run
+type Event struct {
+	Time        time.Time            `json:"time"`
+	Passed      bool                 `json:"passed"`
+	FailedNodes []checker.FailedNode `json:"failed_nodes"`
+	Result      *checker.CheckResult `json:"result"`
+}
+
+func NewEvent(result *checker.CheckResult) Event {
+	return Event{
+		Time:        time.Now().UTC(),
+		Passed:      result.Passed,
+		FailedNodes: result.FailedNodes,
+		Result:      result,
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+// Slack posts a summary of failures to a Slack incoming webhook. Like
+// Webhook, runs without failures are not sent and identical failures are
+// sent only once until they change.
+type Slack struct {
+	url    string
+	client *http.Client
+
+	mu       sync.Mutex
+	lastSent string
+}
+
+func NewSlack(url string) *Slack {
+	return &Slack{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *Slack) Notify(ctx context.Context, result *checker.CheckResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result.Passed {
+		s.lastSent = ""
+		return nil
+	}
+
+	// Skip failures that were already sent
+	fingerprint := failuresFingerprint(newWebhookPayload(result))
+	if fingerprint == s.lastSent {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"text": formatMessage(result, maxMessageFailures),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+
+	if err := postJSON(ctx, s.client, s.url, body, "slack message"); err != nil {
+		return err
+	}
+
+	s.lastSent = fingerprint
+	return nil
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// Telegram sends a summary of failures to a chat via a bot. Like Webhook,
+// runs without failures are not sent and identical failures are sent only
+// once until they change.
+type Telegram struct {
+	token  string
+	chatID string
+	client *http.Client
+
+	mu       sync.Mutex
+	lastSent string
+}
+
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (t *Telegram) Notify(ctx context.Context, result *checker.CheckResult) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if result.Passed {
+		t.lastSent = ""
+		return nil
+	}
+
+	// Skip failures that were already sent
+	fingerprint := failuresFingerprint(newWebhookPayload(result))
+	if fingerprint == t.lastSent {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  t.chatID,
+		"text":                     formatMessage(result, maxMessageFailures),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram message: %w", err)
+	}
+
+	url := telegramAPI + "/bot" + t.token + "/sendMessage"
+	if err := postJSON(ctx, t.client, url, body, "telegram message"); err != nil {
+		// The bot token is part of the URL, keep it out of the error
+		return errors.New(strings.ReplaceAll(err.Error(), t.token, "xxxxx"))
+	}
+
+	t.lastSent = fingerprint
+	return nil
+}
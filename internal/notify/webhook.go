@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// WebhookPayload is the JSON body posted to the webhook
+type WebhookPayload struct {
+	Time        time.Time            `json:"time"`
+	FailedNodes []checker.FailedNode `json:"failed_nodes"`
+	Errors      map[string][]string  `json:"errors,omitempty"`
+}
+
+// Webhook posts failed nodes to a URL. Runs without failures are not sent,
+// and identical failures are sent only once until they change.
+type Webhook struct {
+	url    string
+	client *http.Client
+
+	mu       sync.Mutex
+	lastSent string
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (w *Webhook) Notify(ctx context.Context, result *checker.CheckResult) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if result.Passed {
+		w.lastSent = ""
+		return nil
+	}
+
+	payload := newWebhookPayload(result)
+
+	// Skip failures that were already sent
+	fingerprint := failuresFingerprint(payload)
+	if fingerprint == w.lastSent {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	// Retry once
+	if err := postJSON(ctx, w.client, w.url, body, "webhook"); err != nil {
+		if err := postJSON(ctx, w.client, w.url, body, "webhook"); err != nil {
+			return err
+		}
+	}
+
+	w.lastSent = fingerprint
+	return nil
+}
+
+// newWebhookPayload collects the failed nodes and chain errors of result
+func newWebhookPayload(result *checker.CheckResult) WebhookPayload {
+	payload := WebhookPayload{
+		Time:        time.Now().UTC(),
+		FailedNodes: result.FailedNodes,
+	}
+	for _, chainResult := range result.ChainResults {
+		if len(chainResult.Errors) == 0 {
+			continue
+		}
+		if payload.Errors == nil {
+			payload.Errors = make(map[string][]string)
+		}
+		payload.Errors[chainResult.Chain] = chainResult.Errors
+	}
+
+	return payload
+}
+
+// postJSON posts body to url. name describes the receiver in errors.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %s", name, resp.Status)
+	}
+
+	return nil
+}
+
+// failuresFingerprint returns a stable representation of the failures in
+// payload, ignoring order and time
+func failuresFingerprint(payload WebhookPayload) string {
+	lines := make([]string, 0, len(payload.FailedNodes))
+	for _, fn := range payload.FailedNodes {
+		lines = append(lines, strings.Join([]string{fn.Chain, fn.ID, fn.Address, fn.Reason}, "|"))
+	}
+	for chain, errs := range payload.Errors {
+		for _, reason := range errs {
+			lines = append(lines, chain+"|"+reason)
+		}
+	}
+	slices.Sort(lines)
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+// failedResult returns a failed result with the given failed nodes
+func failedResult(failed ...checker.FailedNode) *checker.CheckResult {
+	return &checker.CheckResult{
+		ChainResults: []checker.ChainResult{{Chain: "ethereum", FailedNodes: failed}},
+		FailedNodes:  failed,
+	}
+}
+
+func TestFailuresFingerprint(t *testing.T) {
+	a := checker.FailedNode{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Reason: "block gap too large"}
+	b := checker.FailedNode{ID: "b", Chain: "ethereum", Address: "https://b.example.com", Reason: "node is still syncing"}
+
+	ab := failuresFingerprint(WebhookPayload{FailedNodes: []checker.FailedNode{a, b}})
+	ba := failuresFingerprint(WebhookPayload{FailedNodes: []checker.FailedNode{b, a}})
+	if ab != ba {
+		t.Errorf("fingerprint depends on order: %q != %q", ab, ba)
+	}
+
+	changed := b
+	changed.Reason = "peer count too low"
+	if ab == failuresFingerprint(WebhookPayload{FailedNodes: []checker.FailedNode{a, changed}}) {
+		t.Error("fingerprint did not change with the failure reason")
+	}
+
+	withErrors := failuresFingerprint(WebhookPayload{
+		FailedNodes: []checker.FailedNode{a, b},
+		Errors:      map[string][]string{"ethereum": {"chain ID split"}},
+	})
+	if ab == withErrors {
+		t.Error("fingerprint did not change with the chain errors")
+	}
+}
+
+func TestWebhookDedup(t *testing.T) {
+	var posts atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			FailedNodes []map[string]any `json:"failed_nodes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		if len(payload.FailedNodes) == 0 {
+			t.Error("payload has no failed nodes")
+		}
+		posts.Add(1)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL)
+	ctx := context.Background()
+
+	a := checker.FailedNode{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Reason: "block gap too large"}
+	b := checker.FailedNode{ID: "b", Chain: "ethereum", Address: "https://b.example.com", Reason: "node is still syncing"}
+
+	steps := []struct {
+		name   string
+		result *checker.CheckResult
+		posts  int64
+	}{
+		{name: "first failure", result: failedResult(a), posts: 1},
+		{name: "same failure", result: failedResult(a), posts: 1},
+		{name: "new failure", result: failedResult(a, b), posts: 2},
+		{name: "same failures reordered", result: failedResult(b, a), posts: 2},
+		{name: "passed", result: &checker.CheckResult{Passed: true}, posts: 2},
+		{name: "failure after pass", result: failedResult(a, b), posts: 3},
+	}
+
+	for _, step := range steps {
+		if err := webhook.Notify(ctx, step.result); err != nil {
+			t.Fatalf("%s: Notify: %v", step.name, err)
+		}
+		if got := posts.Load(); got != step.posts {
+			t.Fatalf("%s: %d posts, want %d", step.name, got, step.posts)
+		}
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int64
+		attempts int64
+		wantErr  bool
+	}{
+		{name: "success", failures: 0, attempts: 1},
+		{name: "retried once", failures: 1, attempts: 2},
+		{name: "retry fails", failures: 2, attempts: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+				}
+			}))
+			defer server.Close()
+
+			webhook := NewWebhook(server.URL)
+			result := failedResult(checker.FailedNode{ID: "a", Chain: "ethereum", Reason: "block gap too large"})
+
+			err := webhook.Notify(context.Background(), result)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify error = %v, want error %t", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("%d attempts, want %d", got, tt.attempts)
+			}
+
+			// Failed deliveries are not deduplicated, the next run sends again
+			if tt.wantErr {
+				if err := webhook.Notify(context.Background(), result); err != nil {
+					t.Errorf("Notify of the next run: %v", err)
+				}
+				if got := attempts.Load(); got != tt.attempts+1 {
+					t.Errorf("%d attempts after the next run, want %d", got, tt.attempts+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Change is how the status of a node changed between two reports
+type Change string
+
+const (
+	ChangeNewlyFailing Change = "newly_failing"
+	ChangeRecovered    Change = "recovered"
+	ChangeStillFailing Change = "still_failing"
+	ChangeStillPassing Change = "still_passing"
+	ChangeAdded        Change = "added"
+	ChangeRemoved      Change = "removed"
+)
+
+// Diff compares the nodes of two reports
+type Diff struct {
+	Nodes []NodeDiff `json:"nodes"`
+}
+
+// NodeDiff is the change of a node between two reports. Failures holds the
+// failure codes of the node in the later report, or in the earlier one if
+// the node was removed. Block numbers and latencies are only compared if
+// the node could be checked in both reports.
+type NodeDiff struct {
+	Chain         string        `json:"chain"`
+	ID            string        `json:"id"`
+	Address       string        `json:"address"`
+	Change        Change        `json:"change"`
+	Failures      []string      `json:"failures,omitempty"`
+	BlockBefore   uint64        `json:"block_before,omitempty"`
+	BlockAfter    uint64        `json:"block_after,omitempty"`
+	BlockDelta    int64         `json:"block_delta,omitempty"`
+	LatencyBefore time.Duration `json:"latency_before,omitempty"`
+	LatencyAfter  time.Duration `json:"latency_after,omitempty"`
+	LatencyDelta  time.Duration `json:"latency_delta,omitempty"`
+}
+
+// nodeKey identifies a node across reports. Addresses are redacted in
+// reports, so nodes of an upstream whose addresses only differ in their
+// secrets are told apart by their order in the report.
+type nodeKey struct {
+	chain, id, address string
+	n                  int
+}
+
+func compareKeys(a, b nodeKey) int {
+	return cmp.Or(
+		cmp.Compare(a.chain, b.chain),
+		cmp.Compare(a.id, b.id),
+		cmp.Compare(a.address, b.address),
+		cmp.Compare(a.n, b.n),
+	)
+}
+
+// nodeState is a node of a report with the codes of its failures
+type nodeState struct {
+	node     Node
+	failures []string
+}
+
+// Compare returns the changes of all nodes from before to after, sorted by
+// chain, id and address
+func Compare(before, after Report) Diff {
+	beforeNodes := reportNodes(before)
+	afterNodes := reportNodes(after)
+
+	keys := slices.Collect(maps.Keys(afterNodes))
+	for key := range beforeNodes {
+		if _, ok := afterNodes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, compareKeys)
+
+	var diff Diff
+	for _, key := range keys {
+		nodeDiff := NodeDiff{
+			Chain:   key.chain,
+			ID:      key.id,
+			Address: key.address,
+		}
+
+		a, inAfter := afterNodes[key]
+		b, inBefore := beforeNodes[key]
+		switch {
+		case !inAfter:
+			nodeDiff.Change = ChangeRemoved
+			nodeDiff.Failures = b.failures
+			diff.Nodes = append(diff.Nodes, nodeDiff)
+			continue
+		case !inBefore:
+			nodeDiff.Change = ChangeAdded
+		case len(b.failures) == 0 && len(a.failures) > 0:
+			nodeDiff.Change = ChangeNewlyFailing
+		case len(b.failures) > 0 && len(a.failures) == 0:
+			nodeDiff.Change = ChangeRecovered
+		case len(a.failures) > 0:
+			nodeDiff.Change = ChangeStillFailing
+		default:
+			nodeDiff.Change = ChangeStillPassing
+		}
+		nodeDiff.Failures = a.failures
+
+		if inBefore && b.node.Error == "" && a.node.Error == "" {
+			nodeDiff.BlockBefore = b.node.BlockNumber
+			nodeDiff.BlockAfter = a.node.BlockNumber
+			nodeDiff.BlockDelta = int64(a.node.BlockNumber) - int64(b.node.BlockNumber)
+			nodeDiff.LatencyBefore = b.node.Timings.Total
+			nodeDiff.LatencyAfter = a.node.Timings.Total
+			nodeDiff.LatencyDelta = a.node.Timings.Total - b.node.Timings.Total
+		}
+
+		diff.Nodes = append(diff.Nodes, nodeDiff)
+	}
+
+	return diff
+}
+
+// Changed returns the nodes whose status changed, i.e. that are newly
+// failing, recovered, added or removed
+func (d Diff) Changed() []NodeDiff {
+	var changed []NodeDiff
+	for _, node := range d.Nodes {
+		if node.Change != ChangeStillFailing && node.Change != ChangeStillPassing {
+			changed = append(changed, node)
+		}
+	}
+	return changed
+}
+
+// NewlyFailing reports whether any node started failing
+func (d Diff) NewlyFailing() bool {
+	return slices.ContainsFunc(d.Nodes, func(node NodeDiff) bool {
+		return node.Change == ChangeNewlyFailing
+	})
+}
+
+// String describes a status change in one line, e.g.
+// "node eth/infura newly failing: block_gap"
+func (n NodeDiff) String() string {
+	name := n.Chain + "/" + n.ID
+	failures := strings.Join(n.Failures, ", ")
+
+	switch n.Change {
+	case ChangeNewlyFailing:
+		return fmt.Sprintf("node %s newly failing: %s", name, failures)
+	case ChangeRecovered:
+		return fmt.Sprintf("node %s recovered", name)
+	case ChangeStillFailing:
+		return fmt.Sprintf("node %s still failing: %s", name, failures)
+	case ChangeAdded:
+		if failures != "" {
+			return fmt.Sprintf("node %s added, failing: %s", name, failures)
+		}
+		return fmt.Sprintf("node %s added", name)
+	case ChangeRemoved:
+		return fmt.Sprintf("node %s removed", name)
+	default:
+		return fmt.Sprintf("node %s passing", name)
+	}
+}
+
+// reportNodes returns the nodes of r with their failure codes, sorted and
+// without duplicates. Chains of reports written before nodes listed their
+// own failures fall back to the failures listed under the chain.
+func reportNodes(r Report) map[nodeKey]nodeState {
+	nodes := make(map[nodeKey]nodeState)
+	for _, chain := range r.ChainResults {
+		ownFailures := slices.ContainsFunc(chain.Nodes, func(node Node) bool {
+			return len(node.Failures) > 0
+		})
+
+		failures := make(map[nodeKey][]string)
+		for _, fn := range chain.FailedNodes {
+			key := nodeKey{chain: chain.Chain, id: fn.ID, address: fn.Address}
+			failures[key] = append(failures[key], fn.Code)
+		}
+
+		seen := make(map[nodeKey]int)
+		for _, node := range chain.Nodes {
+			key := nodeKey{chain: chain.Chain, id: node.ID, address: node.Address}
+			codes := slices.Clone(node.Failures)
+			if !ownFailures {
+				codes = slices.Clone(failures[key])
+			}
+			slices.Sort(codes)
+
+			n := seen[key]
+			seen[key]++
+			key.n = n
+
+			nodes[key] = nodeState{
+				node:     node,
+				failures: slices.Compact(codes),
+			}
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,249 @@
+package report
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+// collidingResult returns a result of two connectors of an upstream whose
+// addresses only differ in their API key, with the connector with key
+// failedKey failing
+func collidingResult(failedKey string) *checker.CheckResult {
+	result := &checker.CheckResult{
+		ChainResults: []checker.ChainResult{{
+			Chain: "ethereum",
+			Nodes: []checker.NodeResult{
+				{ID: "infura", Chain: "ethereum", Address: "https://eth.example.com/?apikey=first"},
+				{ID: "infura", Chain: "ethereum", Address: "https://eth.example.com/?apikey=second"},
+			},
+		}},
+	}
+	if failedKey != "" {
+		failed := checker.FailedNode{
+			ID:      "infura",
+			Chain:   "ethereum",
+			Address: "https://eth.example.com/?apikey=" + failedKey,
+			Code:    checker.ReasonBlockGap,
+			Reason:  "block gap too large",
+		}
+		result.ChainResults[0].FailedNodes = []checker.FailedNode{failed}
+		result.FailedNodes = []checker.FailedNode{failed}
+	}
+	return result
+}
+
+func TestNewRedactsAddresses(t *testing.T) {
+	report := New(collidingResult("second"))
+
+	nodes := report.ChainResults[0].Nodes
+	for i, node := range nodes {
+		if node.Address != "https://eth.example.com/?apikey=xxxxx" {
+			t.Errorf("node %d address = %q, want it redacted", i, node.Address)
+		}
+	}
+	if report.FailedNodes[0].Address != "https://eth.example.com/?apikey=xxxxx" {
+		t.Errorf("failed node address = %q, want it redacted", report.FailedNodes[0].Address)
+	}
+
+	// The failure is listed under the node that failed although both
+	// nodes have the same redacted address
+	if len(nodes[0].Failures) != 0 || len(nodes[1].Failures) != 1 || nodes[1].Failures[0] != "block_gap" {
+		t.Errorf("failures = %v and %v, want none and block_gap", nodes[0].Failures, nodes[1].Failures)
+	}
+}
+
+func TestCompareRedactedAddresses(t *testing.T) {
+	before := New(collidingResult("first"))
+	after := New(collidingResult("second"))
+
+	diff := Compare(before, after)
+	if len(diff.Nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2: %+v", len(diff.Nodes), diff.Nodes)
+	}
+	if diff.Nodes[0].Change != ChangeRecovered || diff.Nodes[1].Change != ChangeNewlyFailing {
+		t.Errorf("changes = %s and %s, want %s and %s", diff.Nodes[0].Change, diff.Nodes[1].Change, ChangeRecovered, ChangeNewlyFailing)
+	}
+}
+
+// testNode returns a checked node of chain with the given failure codes
+func testNode(chain, id string, block uint64, latency time.Duration, failures ...string) Node {
+	return Node{
+		ID:          id,
+		Chain:       chain,
+		Address:     "https://" + id + ".example.com",
+		BlockNumber: block,
+		Timings:     Timings{Total: latency},
+		Failures:    failures,
+	}
+}
+
+// testReport returns a report of nodes, grouped into chains in the order
+// of the nodes
+func testReport(nodes ...Node) Report {
+	var report Report
+	for _, node := range nodes {
+		i := slices.IndexFunc(report.ChainResults, func(c Chain) bool { return c.Chain == node.Chain })
+		if i < 0 {
+			report.ChainResults = append(report.ChainResults, Chain{Chain: node.Chain})
+			i = len(report.ChainResults) - 1
+		}
+		report.ChainResults[i].Nodes = append(report.ChainResults[i].Nodes, node)
+	}
+	return report
+}
+
+func TestCompare(t *testing.T) {
+	down := testNode("ethereum", "a", 0, 0, "connection")
+	down.Error = "failed to connect: connection refused"
+
+	tests := []struct {
+		name   string
+		before []Node
+		after  []Node
+		want   NodeDiff
+		line   string
+	}{
+		{
+			name:   "newly failing",
+			before: []Node{testNode("ethereum", "a", 1000, 100*time.Millisecond)},
+			after:  []Node{testNode("ethereum", "a", 1010, 250*time.Millisecond, "block_gap")},
+			want: NodeDiff{
+				Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeNewlyFailing, Failures: []string{"block_gap"},
+				BlockBefore: 1000, BlockAfter: 1010, BlockDelta: 10,
+				LatencyBefore: 100 * time.Millisecond, LatencyAfter: 250 * time.Millisecond, LatencyDelta: 150 * time.Millisecond,
+			},
+			line: "node ethereum/a newly failing: block_gap",
+		},
+		{
+			name:   "recovered",
+			before: []Node{testNode("ethereum", "a", 1000, 250*time.Millisecond, "syncing", "block_gap")},
+			after:  []Node{testNode("ethereum", "a", 990, 100*time.Millisecond)},
+			want: NodeDiff{
+				Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeRecovered,
+				BlockBefore: 1000, BlockAfter: 990, BlockDelta: -10,
+				LatencyBefore: 250 * time.Millisecond, LatencyAfter: 100 * time.Millisecond, LatencyDelta: -150 * time.Millisecond,
+			},
+			line: "node ethereum/a recovered",
+		},
+		{
+			name:   "still failing",
+			before: []Node{testNode("ethereum", "a", 1000, 0, "block_gap")},
+			after:  []Node{testNode("ethereum", "a", 1000, 0, "syncing", "block_gap")},
+			want: NodeDiff{
+				Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeStillFailing, Failures: []string{"block_gap", "syncing"},
+				BlockBefore: 1000, BlockAfter: 1000,
+			},
+			line: "node ethereum/a still failing: block_gap, syncing",
+		},
+		{
+			name:   "still passing",
+			before: []Node{testNode("ethereum", "a", 1000, 0)},
+			after:  []Node{testNode("ethereum", "a", 1000, 0)},
+			want:   NodeDiff{Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeStillPassing, BlockBefore: 1000, BlockAfter: 1000},
+			line:   "node ethereum/a passing",
+		},
+		{
+			name:  "added",
+			after: []Node{testNode("ethereum", "a", 1000, time.Millisecond)},
+			want:  NodeDiff{Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeAdded},
+			line:  "node ethereum/a added",
+		},
+		{
+			name:  "added failing",
+			after: []Node{testNode("ethereum", "a", 1000, time.Millisecond, "peer_count")},
+			want:  NodeDiff{Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeAdded, Failures: []string{"peer_count"}},
+			line:  "node ethereum/a added, failing: peer_count",
+		},
+		{
+			name:   "removed",
+			before: []Node{testNode("ethereum", "a", 1000, time.Millisecond, "block_gap")},
+			want:   NodeDiff{Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeRemoved, Failures: []string{"block_gap"}},
+			line:   "node ethereum/a removed",
+		},
+		{
+			name:   "newly unreachable",
+			before: []Node{testNode("ethereum", "a", 1000, 100*time.Millisecond)},
+			after:  []Node{down},
+			want:   NodeDiff{Chain: "ethereum", ID: "a", Address: "https://a.example.com", Change: ChangeNewlyFailing, Failures: []string{"connection"}},
+			line:   "node ethereum/a newly failing: connection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Compare(testReport(tt.before...), testReport(tt.after...))
+			if len(diff.Nodes) != 1 {
+				t.Fatalf("got %d nodes, want 1: %+v", len(diff.Nodes), diff.Nodes)
+			}
+			if !reflect.DeepEqual(diff.Nodes[0], tt.want) {
+				t.Errorf("diff = %+v, want %+v", diff.Nodes[0], tt.want)
+			}
+			if line := diff.Nodes[0].String(); line != tt.line {
+				t.Errorf("line = %q, want %q", line, tt.line)
+			}
+
+			changed := tt.want.Change != ChangeStillFailing && tt.want.Change != ChangeStillPassing
+			if got := len(diff.Changed()) == 1; got != changed {
+				t.Errorf("changed = %t, want %t", got, changed)
+			}
+			if got := diff.NewlyFailing(); got != (tt.want.Change == ChangeNewlyFailing) {
+				t.Errorf("newly failing = %t, want %t", got, !got)
+			}
+		})
+	}
+}
+
+func TestCompareChainFailures(t *testing.T) {
+	// Reports without per-node failures list them under the chain only
+	before := testReport(testNode("ethereum", "a", 1000, 0))
+	after := testReport(testNode("ethereum", "a", 1000, 0))
+	after.ChainResults[0].FailedNodes = []FailedNode{
+		{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Code: "syncing"},
+		{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Code: "block_gap"},
+		{ID: "a", Chain: "ethereum", Address: "https://a.example.com", Code: "syncing"},
+	}
+
+	diff := Compare(before, after)
+	if len(diff.Nodes) != 1 || diff.Nodes[0].Change != ChangeNewlyFailing {
+		t.Fatalf("diff = %+v, want a newly failing node", diff.Nodes)
+	}
+	if want := []string{"block_gap", "syncing"}; !slices.Equal(diff.Nodes[0].Failures, want) {
+		t.Errorf("failures = %v, want %v", diff.Nodes[0].Failures, want)
+	}
+}
+
+func TestCompareOrder(t *testing.T) {
+	nodes := []Node{
+		testNode("polygon", "b", 500, 0),
+		testNode("ethereum", "c", 1000, 0, "block_gap"),
+		testNode("polygon", "a", 500, 0),
+		testNode("ethereum", "a", 1000, 0),
+		testNode("arbitrum", "z", 200, 0),
+	}
+	removed := testNode("ethereum", "b", 1000, 0)
+
+	before := testReport(append([]Node{removed}, nodes...)...)
+	reversed := slices.Clone(nodes)
+	slices.Reverse(reversed)
+	after := testReport(reversed...)
+
+	want := []string{"arbitrum/z", "ethereum/a", "ethereum/b", "ethereum/c", "polygon/a", "polygon/b"}
+	for range 10 {
+		diff := Compare(before, after)
+
+		var got []string
+		for _, node := range diff.Nodes {
+			got = append(got, node.Chain+"/"+node.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+		if !reflect.DeepEqual(diff, Compare(before, after)) {
+			t.Fatal("Compare is not deterministic")
+		}
+	}
+}
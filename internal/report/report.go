@@ -0,0 +1,554 @@
+// Package report defines the JSON output of a check run. The types are kept
+// separate from the checker results so that changes to the checker don't
+// change the output. Within a SchemaVersion, fields are only ever added;
+// removing, renaming or changing the type of a field bumps the version.
+package report
+
+import (
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// SchemaVersion is the version of the JSON output
+const SchemaVersion = 1
+
+// Report is the result of a check run. Durations are in nanoseconds and
+// hashes are 0x-prefixed hex strings.
+type Report struct {
+	SchemaVersion int           `json:"schema_version"`
+	ChainResults  []Chain       `json:"chain_results"`
+	FailedNodes   []FailedNode  `json:"failed_nodes"`
+	Passed        bool          `json:"passed"`
+	Summary       Summary       `json:"summary"`
+	Upstreams     []Upstream    `json:"upstreams,omitempty"`
+	SlowestNodes  []NodeLatency `json:"slowest_nodes,omitempty"`
+}
+
+// Chain is the result of the nodes of one chain
+type Chain struct {
+	Chain           string                              `json:"chain"`
+	Nodes           []Node                              `json:"nodes"`
+	ExpectedChainID *big.Int                            `json:"expected_chain_id"`
+	MaxBlockNumber  uint64                              `json:"max_block_number"`
+	BlockTime       time.Duration                       `json:"estimated_block_time,omitempty"`
+	TrustedBlock    *BlockRef                           `json:"trusted_block,omitempty"`
+	Reference       *Node                               `json:"reference,omitempty"`
+	HashConsensus   map[uint64]HashConsensus            `json:"hash_consensus"`
+	TagConsensus    map[string]map[uint64]HashConsensus `json:"tag_hash_consensus,omitempty"`
+	Health          float64                             `json:"health_score"`
+	FailedNodes     []FailedNode                        `json:"failed_nodes"`
+	Errors          []string                            `json:"errors"`
+	Warnings        []string                            `json:"warnings,omitempty"`
+	Passed          bool                                `json:"passed"`
+	Degraded        bool                                `json:"degraded,omitempty"`
+}
+
+// Node is the result of a single node. Error is set if the node could not
+// be checked. Failures holds the codes of the node's failures, sorted and
+// without duplicates; unlike FailedNodes it tells apart nodes whose redacted
+// addresses are the same.
+type Node struct {
+	ID               string              `json:"id"`
+	Chain            string              `json:"chain"`
+	Address          string              `json:"address"`
+	ChainID          *big.Int            `json:"chain_id"`
+	NetVersion       string              `json:"net_version,omitempty"`
+	GenesisHash      string              `json:"genesis_hash,omitempty"`
+	BlockNumber      uint64              `json:"block_number"`
+	BlockGap         uint64              `json:"block_gap"`
+	BlockOffset      int64               `json:"block_offset"`
+	BlockHashes      map[uint64]string   `json:"block_hashes"`
+	BlockHeaders     map[uint64]Header   `json:"block_headers,omitempty"`
+	TagBlocks        map[string]BlockRef `json:"tag_blocks,omitempty"`
+	DebugOK          bool                `json:"debug_ok"`
+	DebugMethod      string              `json:"debug_method,omitempty"`
+	Syncing          bool                `json:"syncing"`
+	Reorged          bool                `json:"reorged"`
+	HeadAdvance      *uint64             `json:"head_advance,omitempty"`
+	PeerCount        *uint64             `json:"peer_count"`
+	ClientVersion    string              `json:"client_version,omitempty"`
+	ClientName       string              `json:"client_name,omitempty"`
+	ClientSemver     string              `json:"client_semver,omitempty"`
+	GasPrice         *big.Int            `json:"gas_price,omitempty"`
+	BaseFee          *big.Int            `json:"base_fee,omitempty"`
+	TxPool           *TxPool             `json:"txpool,omitempty"`
+	Logs             *Logs               `json:"logs,omitempty"`
+	SmokeCall        *SmokeCall          `json:"smoke_call,omitempty"`
+	CustomCheck      *CustomCheck        `json:"custom_check,omitempty"`
+	SyncStatus       *SyncStatus         `json:"sync_status,omitempty"`
+	TrustedBlockHash string              `json:"trusted_block_hash,omitempty"`
+	PinnedHashes     map[uint64]string   `json:"pinned_hashes,omitempty"`
+	ArchiveOK        bool                `json:"archive_ok"`
+	ArchiveError     string              `json:"archive_error,omitempty"`
+	BlockOK          bool                `json:"block_ok"`
+	BlockError       string              `json:"block_error,omitempty"`
+	RateLimited      bool                `json:"rate_limited,omitempty"`
+	Timings          Timings             `json:"timings"`
+	Warnings         []string            `json:"warnings,omitempty"`
+	Trusted          bool                `json:"trusted,omitempty"`
+	Priority         string              `json:"priority,omitempty"`
+	CachedChecks     []string            `json:"cached_checks,omitempty"`
+	HashMismatch     bool                `json:"hash_mismatch"`
+	Health           float64             `json:"health_score"`
+	Failures         []string            `json:"failures,omitempty"`
+	Error            string              `json:"error,omitempty"`
+}
+
+// Timings holds the duration of each check of a node
+type Timings struct {
+	Dial          time.Duration            `json:"dial"`
+	ChainID       time.Duration            `json:"chain_id"`
+	Genesis       time.Duration            `json:"genesis"`
+	BlockNumber   time.Duration            `json:"block_number"`
+	SyncStatus    time.Duration            `json:"sync_status"`
+	PeerCount     time.Duration            `json:"peer_count"`
+	NetVersion    time.Duration            `json:"net_version"`
+	ClientVersion time.Duration            `json:"client_version"`
+	GasPrice      time.Duration            `json:"gas_price"`
+	BaseFee       time.Duration            `json:"base_fee"`
+	Blocks        map[uint64]time.Duration `json:"blocks"`
+	Tags          map[string]time.Duration `json:"tags,omitempty"`
+	Debug         time.Duration            `json:"debug"`
+	Archive       time.Duration            `json:"archive"`
+	DeepBlock     time.Duration            `json:"deep_block"`
+	TxPool        time.Duration            `json:"txpool"`
+	GetLogs       time.Duration            `json:"get_logs"`
+	SmokeCall     time.Duration            `json:"smoke_call"`
+	CustomCheck   time.Duration            `json:"custom_check"`
+	Reorg         time.Duration            `json:"reorg"`
+	Liveness      time.Duration            `json:"liveness"`
+	Total         time.Duration            `json:"total"`
+}
+
+// BlockRef identifies a block by number and hash
+type BlockRef struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// Header holds the header fields compared between nodes
+type Header struct {
+	Number     uint64 `json:"number"`
+	ParentHash string `json:"parent_hash"`
+	StateRoot  string `json:"state_root"`
+}
+
+// HashConsensus is the majority vote on the hash of a block. MajorityHash
+// is null if there was no majority.
+type HashConsensus struct {
+	MajorityHash  *string             `json:"majority_hash"`
+	MajorityVotes int                 `json:"majority_votes"`
+	Dissenting    map[string][]string `json:"dissenting,omitempty"`
+}
+
+// SyncStatus holds the sync progress reported by eth_syncing
+type SyncStatus struct {
+	StartingBlock uint64 `json:"starting_block"`
+	CurrentBlock  uint64 `json:"current_block"`
+	HighestBlock  uint64 `json:"highest_block"`
+}
+
+// TxPool holds the size of the transaction pool
+type TxPool struct {
+	Method  string `json:"method"`
+	Pending uint64 `json:"pending"`
+	Queued  uint64 `json:"queued"`
+}
+
+// Logs is the result of the eth_getLogs check
+type Logs struct {
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block"`
+	Count     int    `json:"count"`
+	Limited   bool   `json:"limited"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SmokeCall is the result of the smoke eth_call
+type SmokeCall struct {
+	To     string `json:"to"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CustomCheck is the result of the custom check command
+type CustomCheck struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// FailedNode is a failed check of a node. Code is one of the failure codes
+// listed in the README, Reason describes the failure.
+type FailedNode struct {
+	ID      string `json:"id"`
+	Chain   string `json:"chain"`
+	Address string `json:"address"`
+	Code    string `json:"code"`
+	Reason  string `json:"reason"`
+
+	// Priority is "primary" for failures of primary connectors
+	Priority string `json:"priority,omitempty"`
+}
+
+// Summary holds the totals of a check run. Failures counts failures by
+// category.
+type Summary struct {
+	TotalChains int            `json:"total_chains"`
+	TotalNodes  int            `json:"total_nodes"`
+	PassedNodes int            `json:"passed_nodes"`
+	FailedNodes int            `json:"failed_nodes"`
+	WarnedNodes int            `json:"warned_nodes"`
+	RateLimited int            `json:"rate_limited_nodes"`
+	Failures    map[string]int `json:"failures"`
+}
+
+// Upstream counts the nodes of a chain per upstream id
+type Upstream struct {
+	ID          string `json:"id"`
+	Chain       string `json:"chain"`
+	TotalNodes  int    `json:"total_nodes"`
+	PassedNodes int    `json:"passed_nodes"`
+	FailedNodes int    `json:"failed_nodes"`
+}
+
+// NodeLatency is the total check time of a node
+type NodeLatency struct {
+	ID      string        `json:"id"`
+	Chain   string        `json:"chain"`
+	Address string        `json:"address"`
+	Total   time.Duration `json:"total"`
+}
+
+// New converts result to a Report. Upstreams and SlowestNodes are left
+// empty, they are only set on request.
+func New(result *checker.CheckResult) Report {
+	report := Report{
+		SchemaVersion: SchemaVersion,
+		ChainResults:  make([]Chain, 0, len(result.ChainResults)),
+		FailedNodes:   newFailedNodes(result.FailedNodes),
+		Passed:        result.Passed,
+		Summary:       newSummary(result.Summary()),
+	}
+
+	for _, chainResult := range result.ChainResults {
+		report.ChainResults = append(report.ChainResults, newChain(chainResult))
+	}
+
+	return report
+}
+
+// FailedChains returns the chains with failed nodes or chain errors, sorted
+// by name. Chains that passed with failed nodes are included.
+func (r Report) FailedChains() []string {
+	var chains []string
+	for _, chain := range r.ChainResults {
+		if !chain.Passed || len(chain.FailedNodes) > 0 || len(chain.Errors) > 0 {
+			chains = append(chains, chain.Chain)
+		}
+	}
+	slices.Sort(chains)
+
+	return slices.Compact(chains)
+}
+
+// NewUpstreams converts the per-upstream summaries of result
+func NewUpstreams(upstreams []checker.UpstreamSummary) []Upstream {
+	var result []Upstream
+	for _, u := range upstreams {
+		result = append(result, Upstream{
+			ID:          u.ID,
+			Chain:       u.Chain,
+			TotalNodes:  u.TotalNodes,
+			PassedNodes: u.PassedNodes,
+			FailedNodes: u.FailedNodes,
+		})
+	}
+
+	return result
+}
+
+// NewSlowestNodes converts the slowest nodes of result
+func NewSlowestNodes(nodes []checker.NodeLatency) []NodeLatency {
+	var result []NodeLatency
+	for _, n := range nodes {
+		result = append(result, NodeLatency{
+			ID:      n.ID,
+			Chain:   n.Chain,
+			Address: config.RedactURL(n.Address),
+			Total:   n.Total,
+		})
+	}
+
+	return result
+}
+
+func newChain(r checker.ChainResult) Chain {
+	chain := Chain{
+		Chain:           r.Chain,
+		Nodes:           make([]Node, 0, len(r.Nodes)),
+		ExpectedChainID: r.ExpectedChainID,
+		MaxBlockNumber:  r.MaxBlockNumber,
+		BlockTime:       r.EstimatedBlockTime,
+		TrustedBlock:    newBlockRef(r.TrustedBlock),
+		HashConsensus:   make(map[uint64]HashConsensus, len(r.HashConsensus)),
+		Health:          r.Health,
+		FailedNodes:     newFailedNodes(r.FailedNodes),
+		Errors:          r.Errors,
+		Warnings:        r.Warnings,
+		Passed:          r.Passed,
+		Degraded:        r.Degraded,
+	}
+
+	// Failures are matched by the full address, which is redacted in the
+	// report
+	for _, node := range r.Nodes {
+		converted := newNode(node)
+		for _, fn := range r.FailedNodes {
+			if fn.Address == node.Address {
+				converted.Failures = append(converted.Failures, fn.Code.String())
+			}
+		}
+		slices.Sort(converted.Failures)
+		converted.Failures = slices.Compact(converted.Failures)
+
+		chain.Nodes = append(chain.Nodes, converted)
+	}
+
+	if r.Reference != nil {
+		reference := newNode(*r.Reference)
+		chain.Reference = &reference
+	}
+
+	for block, info := range r.HashConsensus {
+		chain.HashConsensus[block] = newHashConsensus(info)
+	}
+
+	if len(r.TagHashConsensus) > 0 {
+		chain.TagConsensus = make(map[string]map[uint64]HashConsensus, len(r.TagHashConsensus))
+		for tag, blocks := range r.TagHashConsensus {
+			chain.TagConsensus[tag] = make(map[uint64]HashConsensus, len(blocks))
+			for block, info := range blocks {
+				chain.TagConsensus[tag][block] = newHashConsensus(info)
+			}
+		}
+	}
+
+	return chain
+}
+
+func newHashConsensus(info checker.HashConsensusInfo) HashConsensus {
+	consensus := HashConsensus{
+		MajorityHash:  hashPtr(info.MajorityHash),
+		MajorityVotes: info.MajorityVotes,
+	}
+	if len(info.Dissenting) > 0 {
+		consensus.Dissenting = make(map[string][]string, len(info.Dissenting))
+		for hash, ids := range info.Dissenting {
+			consensus.Dissenting[hash.Hex()] = ids
+		}
+	}
+
+	return consensus
+}
+
+func newNode(n checker.NodeResult) Node {
+	node := Node{
+		ID:               n.ID,
+		Chain:            n.Chain,
+		Address:          config.RedactURL(n.Address),
+		ChainID:          n.ChainID,
+		NetVersion:       n.NetVersion,
+		GenesisHash:      hashString(n.GenesisHash),
+		BlockNumber:      n.BlockNumber,
+		BlockGap:         n.BlockGap,
+		BlockOffset:      n.BlockOffset,
+		BlockHashes:      make(map[uint64]string, len(n.BlockHashes)),
+		DebugOK:          n.DebugOK,
+		DebugMethod:      n.DebugMethod,
+		Syncing:          n.Syncing,
+		Reorged:          n.Reorged,
+		HeadAdvance:      n.HeadAdvance,
+		PeerCount:        n.PeerCount,
+		ClientVersion:    n.ClientVersion,
+		ClientName:       n.ClientName,
+		ClientSemver:     n.ClientSemver,
+		GasPrice:         n.GasPrice,
+		BaseFee:          n.BaseFee,
+		TrustedBlockHash: hashString(n.TrustedBlockHash),
+		ArchiveOK:        n.ArchiveOK,
+		ArchiveError:     n.ArchiveError,
+		BlockOK:          n.BlockOK,
+		BlockError:       n.BlockError,
+		RateLimited:      n.RateLimited,
+		Timings:          newTimings(n.Timings),
+		Warnings:         n.Warnings,
+		Trusted:          n.Trusted,
+		Priority:         n.Priority,
+		CachedChecks:     n.CachedChecks,
+		HashMismatch:     n.HashMismatch,
+		Health:           n.Health,
+	}
+
+	for block, hash := range n.BlockHashes {
+		node.BlockHashes[block] = hash.Hex()
+	}
+
+	if len(n.PinnedHashes) > 0 {
+		node.PinnedHashes = make(map[uint64]string, len(n.PinnedHashes))
+		for block, hash := range n.PinnedHashes {
+			node.PinnedHashes[block] = hash.Hex()
+		}
+	}
+
+	if len(n.BlockHeaders) > 0 {
+		node.BlockHeaders = make(map[uint64]Header, len(n.BlockHeaders))
+		for block, header := range n.BlockHeaders {
+			node.BlockHeaders[block] = Header{
+				Number:     header.Number,
+				ParentHash: header.ParentHash.Hex(),
+				StateRoot:  header.StateRoot.Hex(),
+			}
+		}
+	}
+
+	if len(n.TagBlocks) > 0 {
+		node.TagBlocks = make(map[string]BlockRef, len(n.TagBlocks))
+		for tag, ref := range n.TagBlocks {
+			node.TagBlocks[tag] = *newBlockRef(&ref)
+		}
+	}
+
+	if n.TxPool != nil {
+		node.TxPool = &TxPool{
+			Method:  n.TxPool.Method,
+			Pending: n.TxPool.Pending,
+			Queued:  n.TxPool.Queued,
+		}
+	}
+
+	if n.Logs != nil {
+		node.Logs = &Logs{
+			FromBlock: n.Logs.FromBlock,
+			ToBlock:   n.Logs.ToBlock,
+			Count:     n.Logs.Count,
+			Limited:   n.Logs.Limited,
+			Error:     n.Logs.Error,
+		}
+	}
+
+	if n.SmokeCall != nil {
+		node.SmokeCall = &SmokeCall{
+			To:     n.SmokeCall.To,
+			Result: n.SmokeCall.Result,
+			Error:  n.SmokeCall.Error,
+		}
+	}
+
+	if n.CustomCheck != nil {
+		node.CustomCheck = &CustomCheck{
+			OK:    n.CustomCheck.OK,
+			Error: n.CustomCheck.Error,
+		}
+	}
+
+	if n.SyncStatus != nil {
+		node.SyncStatus = &SyncStatus{
+			StartingBlock: n.SyncStatus.StartingBlock,
+			CurrentBlock:  n.SyncStatus.CurrentBlock,
+			HighestBlock:  n.SyncStatus.HighestBlock,
+		}
+	}
+
+	if n.Error != nil {
+		node.Error = n.Error.Error()
+	}
+
+	return node
+}
+
+func newTimings(t checker.Timings) Timings {
+	return Timings{
+		Dial:          t.Dial,
+		ChainID:       t.ChainID,
+		Genesis:       t.Genesis,
+		BlockNumber:   t.BlockNumber,
+		SyncStatus:    t.SyncStatus,
+		PeerCount:     t.PeerCount,
+		NetVersion:    t.NetVersion,
+		ClientVersion: t.ClientVersion,
+		GasPrice:      t.GasPrice,
+		BaseFee:       t.BaseFee,
+		Blocks:        t.Blocks,
+		Tags:          t.Tags,
+		Debug:         t.Debug,
+		Archive:       t.Archive,
+		DeepBlock:     t.DeepBlock,
+		TxPool:        t.TxPool,
+		GetLogs:       t.GetLogs,
+		SmokeCall:     t.SmokeCall,
+		CustomCheck:   t.CustomCheck,
+		Reorg:         t.Reorg,
+		Liveness:      t.Liveness,
+		Total:         t.Total,
+	}
+}
+
+func newFailedNodes(nodes []checker.FailedNode) []FailedNode {
+	result := make([]FailedNode, 0, len(nodes))
+	for _, fn := range nodes {
+		result = append(result, FailedNode{
+			ID:       fn.ID,
+			Chain:    fn.Chain,
+			Address:  config.RedactURL(fn.Address),
+			Code:     fn.Code.String(),
+			Reason:   fn.Reason,
+			Priority: fn.Priority,
+		})
+	}
+
+	return result
+}
+
+func newSummary(s checker.Summary) Summary {
+	return Summary{
+		TotalChains: s.TotalChains,
+		TotalNodes:  s.TotalNodes,
+		PassedNodes: s.PassedNodes,
+		FailedNodes: s.FailedNodes,
+		WarnedNodes: s.WarnedNodes,
+		RateLimited: s.RateLimited,
+		Failures:    s.Failures,
+	}
+}
+
+func newBlockRef(ref *checker.BlockRef) *BlockRef {
+	if ref == nil {
+		return nil
+	}
+
+	return &BlockRef{
+		Number: ref.Number,
+		Hash:   ref.Hash.Hex(),
+	}
+}
+
+func hashString(hash *common.Hash) string {
+	if hash == nil {
+		return ""
+	}
+	return hash.Hex()
+}
+
+func hashPtr(hash *common.Hash) *string {
+	if hash == nil {
+		return nil
+	}
+
+	hex := hash.Hex()
+	return &hex
+}
@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package store
+
+// The pure Go SQLite driver registers itself as "sqlite". It is optional so
+// that default builds don't carry it.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,194 @@
+// Package store keeps the results of every run in a SQLite database for
+// trend analysis. The SQLite driver is only linked into binaries built with
+// the sqlite build tag, see driver.go.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// driverName is the database/sql name of the SQLite driver
+const driverName = "sqlite"
+
+// migrations create and update the schema. Each entry is applied once, in
+// order, and its index is recorded in schema_version. Never edit applied
+// migrations, append new ones instead.
+var migrations = []string{
+	`CREATE TABLE node_results (
+		run_at       INTEGER NOT NULL,
+		chain        TEXT    NOT NULL,
+		node_id      TEXT    NOT NULL,
+		address      TEXT    NOT NULL,
+		block_number INTEGER,
+		latency_ms   INTEGER NOT NULL,
+		passed       INTEGER NOT NULL,
+		reason       TEXT    NOT NULL
+	);
+	CREATE INDEX node_results_run_at ON node_results (run_at);
+	CREATE INDEX node_results_node ON node_results (chain, node_id, run_at);`,
+}
+
+// Row is the stored result of a node in one run
+type Row struct {
+	RunAt       time.Time
+	Chain       string
+	NodeID      string
+	Address     string
+	BlockNumber *uint64
+	Latency     time.Duration
+	Passed      bool
+	Reason      string
+}
+
+// Store writes run results to a SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the SQLite database at path, creating it if needed, and
+// migrates it to the latest schema
+func Open(ctx context.Context, path string) (*Store, error) {
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return nil, errors.New("SQLite support is not built in, rebuild with -tags sqlite")
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	s := &Store{db: db}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies the migrations that have not been applied yet
+func (s *Store) migrate(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Record writes one row per node of result. Failed nodes are stored with
+// their failure reasons joined with "; ".
+func (s *Store) Record(ctx context.Context, runAt time.Time, result *checker.CheckResult) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO node_results
+		(run_at, chain, node_id, address, block_number, latency_ms, passed, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, chainResult := range result.ChainResults {
+		for _, node := range chainResult.Nodes {
+			var reasons []string
+			for _, fn := range chainResult.FailedNodes {
+				if fn.Address == node.Address {
+					reasons = append(reasons, fn.Reason)
+				}
+			}
+
+			var blockNumber *uint64
+			if node.Error == nil {
+				blockNumber = &node.BlockNumber
+			}
+
+			if _, err := stmt.ExecContext(ctx,
+				runAt.UnixMilli(),
+				node.Chain,
+				node.ID,
+				config.RedactURL(node.Address),
+				blockNumber,
+				node.Timings.Total.Milliseconds(),
+				len(reasons) == 0,
+				strings.Join(reasons, "; "),
+			); err != nil {
+				return fmt.Errorf("failed to insert result of node %s: %w", node.ID, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Rows returns the rows recorded since the given time, oldest first
+func (s *Store) Rows(ctx context.Context, since time.Time) ([]Row, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT run_at, chain, node_id, address, block_number, latency_ms, passed, reason
+		FROM node_results WHERE run_at >= ? ORDER BY run_at, chain, node_id`, since.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Row
+	for rows.Next() {
+		var (
+			row         Row
+			runAt       int64
+			blockNumber sql.NullInt64
+			latencyMs   int64
+		)
+		if err := rows.Scan(&runAt, &row.Chain, &row.NodeID, &row.Address, &blockNumber, &latencyMs, &row.Passed, &row.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan result: %w", err)
+		}
+
+		row.RunAt = time.UnixMilli(runAt).UTC()
+		row.Latency = time.Duration(latencyMs) * time.Millisecond
+		if blockNumber.Valid {
+			n := uint64(blockNumber.Int64)
+			row.BlockNumber = &n
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
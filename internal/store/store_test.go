@@ -0,0 +1,116 @@
+//go:build sqlite
+
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/checker"
+)
+
+func TestRecordAndRows(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "results.db")
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	result := &checker.CheckResult{
+		ChainResults: []checker.ChainResult{{
+			Chain: "ethereum",
+			Nodes: []checker.NodeResult{
+				{
+					ID:          "a",
+					Chain:       "ethereum",
+					Address:     "https://a.example.com",
+					BlockNumber: 100,
+					Timings:     checker.Timings{Total: 150 * time.Millisecond},
+				},
+				{
+					ID:      "b",
+					Chain:   "ethereum",
+					Address: "https://b.example.com",
+					Error:   errors.New("connection refused"),
+					Timings: checker.Timings{Total: 2 * time.Second},
+				},
+			},
+			FailedNodes: []checker.FailedNode{
+				{ID: "b", Chain: "ethereum", Address: "https://b.example.com", Reason: "connection error"},
+				{ID: "b", Chain: "ethereum", Address: "https://b.example.com", Reason: "block gap"},
+			},
+		}},
+	}
+
+	runAt := time.UnixMilli(1_700_000_000_000).UTC()
+	if err := s.Record(ctx, runAt, result); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reopening an up to date database must not apply the migrations again
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+
+	rows, err := s.Rows(ctx, runAt)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	a, b := rows[0], rows[1]
+	if !a.RunAt.Equal(runAt) || a.Chain != "ethereum" || a.NodeID != "a" || a.Address != "https://a.example.com" {
+		t.Errorf("unexpected row a: %+v", a)
+	}
+	if a.BlockNumber == nil || *a.BlockNumber != 100 {
+		t.Errorf("row a block number = %v, want 100", a.BlockNumber)
+	}
+	if !a.Passed || a.Reason != "" || a.Latency != 150*time.Millisecond {
+		t.Errorf("unexpected row a: %+v", a)
+	}
+
+	if b.NodeID != "b" || b.BlockNumber != nil || b.Passed {
+		t.Errorf("unexpected row b: %+v", b)
+	}
+	if want := "connection error; block gap"; b.Reason != want {
+		t.Errorf("row b reason = %q, want %q", b.Reason, want)
+	}
+
+	later, err := s.Rows(ctx, runAt.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	if len(later) != 0 {
+		t.Errorf("got %d rows after the run, want 0", len(later))
+	}
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "results.db")
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, len(migrations)+1); err != nil {
+		t.Fatalf("bump schema version: %v", err)
+	}
+	s.Close()
+
+	if _, err := Open(ctx, path); err == nil {
+		t.Fatal("Open of a newer schema succeeded, want error")
+	}
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// getBaseFee fetches the base fee of the latest block via eth_feeHistory.
+// Nodes and chains without EIP-1559 support are left without a base fee.
+func (c *Checker) getBaseFee(ctx context.Context, ethClient *ethclient.Client, info *NodeResult) {
+	start := time.Now()
+	feeHistory, err := ethClient.FeeHistory(ctx, 1, nil, nil)
+	info.Timings.BaseFee = time.Since(start)
+	if err != nil {
+		c.log(ctx).Debug("failed to get fee history",
+			"error", err)
+		return
+	}
+
+	if len(feeHistory.BaseFee) == 0 || feeHistory.BaseFee[0] == nil {
+		return
+	}
+	info.BaseFee = feeHistory.BaseFee[0]
+}
+
+// checkBaseFees reports nodes whose base fee deviates from the chain's median
+// by more than Options.BaseFeeTolerance percent. Like the gas price check it
+// is skipped with fewer than 3 base fees, and for chains without base fees.
+func (c *Checker) checkBaseFees(result *ChainResult) {
+	var fees []*big.Int
+	for _, node := range result.Nodes {
+		if node.Error == nil && node.BaseFee != nil {
+			fees = append(fees, node.BaseFee)
+		}
+	}
+
+	if len(fees) < 3 {
+		return
+	}
+
+	median := medianBigInt(fees)
+	if median.Sign() == 0 {
+		return
+	}
+
+	for _, node := range result.Nodes {
+		if node.Error != nil || node.BaseFee == nil {
+			continue
+		}
+
+		deviation := deviationPercent(node.BaseFee, median)
+		if deviation > c.opts.BaseFeeTolerance {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonBaseFee,
+				Reason:  fmt.Sprintf("base fee deviates from median: %s vs %s (%.1f%%, max allowed: %.1f%%)", node.BaseFee, median, deviation, c.opts.BaseFeeTolerance),
+			})
+			result.Passed = false
+		}
+	}
+}
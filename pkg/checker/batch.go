@@ -0,0 +1,103 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxBatchSize limits the blocks fetched in one batch request. Servers limit
+// the size of batches, geth to 1000 requests by default.
+const maxBatchSize = 100
+
+// fetchBlockHeaders returns the header of each block, or the error of
+// fetching it. Blocks are fetched in batches of up to maxBatchSize; if the
+// server rejects a batch, its blocks are fetched one by one. The duration of
+// each request is recorded in timings, blocks of a batch share its duration.
+func (c *Checker) fetchBlockHeaders(ctx context.Context, rpcClient *rpc.Client, blocks []uint64, timings map[uint64]time.Duration) ([]*blockHeader, []error) {
+	headers := make([]*blockHeader, 0, len(blocks))
+	errs := make([]error, 0, len(blocks))
+
+	for chunk := range slices.Chunk(blocks, maxBatchSize) {
+		start := time.Now()
+		batchHeaders, batchErrs, err := getBlockHeaders(ctx, rpcClient, chunk)
+		duration := time.Since(start)
+		if err == nil {
+			for _, block := range chunk {
+				timings[block] = duration
+			}
+			headers = append(headers, batchHeaders...)
+			errs = append(errs, batchErrs...)
+			continue
+		}
+
+		c.log(ctx).Debug("batch request failed, fetching blocks one by one",
+			"error", err)
+
+		for _, block := range chunk {
+			start := time.Now()
+			header, err := getBlockHeader(ctx, rpcClient, fmt.Sprintf("0x%x", block))
+			timings[block] = time.Since(start)
+			headers = append(headers, header)
+			errs = append(errs, err)
+		}
+	}
+
+	return headers, errs
+}
+
+// getBlockHeaders fetches the headers of blocks in a single batch request.
+// Errors are returned per block as by getBlockHeader, so one bad element
+// doesn't fail the others. An error is returned if the server rejects the
+// batch, or fails every element of it, so the caller can fall back to
+// single requests.
+func getBlockHeaders(ctx context.Context, rpcClient *rpc.Client, blocks []uint64) ([]*blockHeader, []error, error) {
+	raws := make([]json.RawMessage, len(blocks))
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i, block := range blocks {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{fmt.Sprintf("0x%x", block), false},
+			Result: &raws[i],
+		}
+	}
+
+	if err := rpcClient.BatchCallContext(ctx, batch); err != nil {
+		return nil, nil, fmt.Errorf("batch request failed: %w", err)
+	}
+
+	headers := make([]*blockHeader, len(blocks))
+	errs := make([]error, len(blocks))
+	failed := 0
+	for i, elem := range batch {
+		if elem.Error != nil {
+			errs[i] = elem.Error
+			failed++
+			continue
+		}
+
+		if len(raws[i]) == 0 || string(raws[i]) == "null" {
+			errs[i] = errBlockNotFound
+			continue
+		}
+
+		var header blockHeader
+		if err := json.Unmarshal(raws[i], &header); err != nil {
+			errs[i] = fmt.Errorf("failed to unmarshal block header: %w", err)
+			continue
+		}
+		headers[i] = &header
+	}
+
+	// Servers without batch support may answer every element with an error
+	if failed > 0 && failed == len(blocks) {
+		return nil, nil, fmt.Errorf("batch request failed: %w", errors.Join(errs...))
+	}
+
+	return headers, errs, nil
+}
@@ -0,0 +1,139 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestFetchBlockHeaders(t *testing.T) {
+	blocks := []uint64{1000, 999, 998, 997, 996}
+
+	tests := []struct {
+		name   string
+		setup  func(m *mockNode)
+		failed []uint64 // blocks that fail
+		// requests is the number of HTTP requests, 1 for a single batch
+		requests int64
+	}{
+		{name: "batch", requests: 1},
+		{
+			name: "out of order",
+			setup: func(m *mockNode) {
+				m.batch = func(resps []rpcResponse) []rpcResponse {
+					slices.Reverse(resps)
+					return resps
+				}
+			},
+			requests: 1,
+		},
+		{
+			name: "partial",
+			setup: func(m *mockNode) {
+				// The response to block 998 is missing
+				m.batch = func(resps []rpcResponse) []rpcResponse {
+					return slices.Delete(resps, 2, 3)
+				}
+			},
+			failed:   []uint64{998},
+			requests: 1,
+		},
+		{
+			name: "element error",
+			setup: func(m *mockNode) {
+				m.handle = func(method string, params []json.RawMessage) (any, bool) {
+					var block string
+					json.Unmarshal(params[0], &block)
+					if method == "eth_getBlockByNumber" && block == hexutil.EncodeUint64(997) {
+						return &rpcError{Code: -32000, Message: "header not found"}, true
+					}
+					return nil, false
+				}
+			},
+			failed:   []uint64{997},
+			requests: 1,
+		},
+		{
+			name: "batch not supported",
+			setup: func(m *mockNode) {
+				m.batch = func(resps []rpcResponse) []rpcResponse {
+					for i := range resps {
+						resps[i].Result = nil
+						resps[i].Error = &rpcError{Code: -32600, Message: "batch requests are not supported"}
+					}
+					return resps
+				}
+			},
+			requests: 1 + int64(len(blocks)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newMockNode(t, 1, 1000)
+			if tt.setup != nil {
+				tt.setup(node)
+			}
+
+			rpcClient, err := rpc.DialContext(context.Background(), node.server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rpcClient.Close()
+
+			c := newTestChecker(t, nil, testOptions())
+			timings := make(map[uint64]time.Duration)
+			headers, errs := c.fetchBlockHeaders(context.Background(), rpcClient, blocks, timings)
+
+			if len(headers) != len(blocks) || len(errs) != len(blocks) {
+				t.Fatalf("got %d headers and %d errors, want %d", len(headers), len(errs), len(blocks))
+			}
+			for i, block := range blocks {
+				if slices.Contains(tt.failed, block) {
+					if errs[i] == nil {
+						t.Errorf("block %d: got header, want error", block)
+					}
+					continue
+				}
+				if errs[i] != nil {
+					t.Errorf("block %d: %v", block, errs[i])
+					continue
+				}
+				// Headers are matched to their blocks whatever the order of
+				// the responses
+				if uint64(headers[i].Number) != block || headers[i].Hash != testHash(1, block, false) {
+					t.Errorf("block %d: got header of block %d", block, headers[i].Number)
+				}
+				if _, ok := timings[block]; !ok {
+					t.Errorf("block %d: no timing", block)
+				}
+			}
+
+			if got := node.requests.Load(); got != tt.requests {
+				t.Errorf("%d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestFetchBlockHeadersNotFound(t *testing.T) {
+	node := newMockNode(t, 1, 1000)
+
+	rpcClient, err := rpc.DialContext(context.Background(), node.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcClient.Close()
+
+	c := newTestChecker(t, nil, testOptions())
+	_, errs := c.fetchBlockHeaders(context.Background(), rpcClient, []uint64{1000, 1001}, make(map[uint64]time.Duration))
+	if errs[0] != nil || !errors.Is(errs[1], errBlockNotFound) {
+		t.Errorf("errors = %v, want block 1001 not found", errs)
+	}
+}
@@ -0,0 +1,88 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// fullBlock holds the fields of a block with full transactions that are not
+// part of types.Header
+type fullBlock struct {
+	Hash         common.Hash          `json:"hash"`
+	Transactions []*types.Transaction `json:"transactions"`
+}
+
+// checkBlockConsistency fetches a block with full transactions and verifies
+// that it is internally consistent: the header hashes to the reported block
+// hash, the transaction count matches eth_getBlockTransactionCountByNumber
+// and the transactions hash to the header's transactionsRoot. The first
+// inconsistency is recorded in info.BlockError.
+func (c *Checker) checkBlockConsistency(ctx context.Context, rpcClient *rpc.Client, blockNumber uint64, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.DeepBlock = time.Since(start)
+	}()
+
+	blockNumberHex := fmt.Sprintf("0x%x", blockNumber)
+
+	var raw json.RawMessage
+	if err := rpcClient.CallContext(ctx, &raw, "eth_getBlockByNumber", blockNumberHex, true); err != nil {
+		info.BlockError = fmt.Sprintf("failed to get block: %v", err)
+		return
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		info.BlockError = errBlockNotFound.Error()
+		return
+	}
+
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		info.BlockError = fmt.Sprintf("invalid block header: %v", err)
+		return
+	}
+
+	var block fullBlock
+	if err := json.Unmarshal(raw, &block); err != nil {
+		// Chains with their own transaction types (e.g. L2 deposits) can't be
+		// verified, which is not a node failure
+		if errors.Is(err, types.ErrTxTypeNotSupported) {
+			c.log(ctx).Debug("block consistency check skipped",
+				"block", blockNumber,
+				"error", err)
+			return
+		}
+		info.BlockError = fmt.Sprintf("invalid block transactions: %v", err)
+		return
+	}
+
+	if hash := header.Hash(); hash != block.Hash {
+		info.BlockError = fmt.Sprintf("header hashes to %s but block hash is %s", hash.Hex(), block.Hash.Hex())
+		return
+	}
+
+	var txCount hexutil.Uint
+	if err := rpcClient.CallContext(ctx, &txCount, "eth_getBlockTransactionCountByNumber", blockNumberHex); err != nil {
+		info.BlockError = fmt.Sprintf("failed to get transaction count: %v", err)
+		return
+	}
+	if int(txCount) != len(block.Transactions) {
+		info.BlockError = fmt.Sprintf("block has %d transactions but transaction count is %d", len(block.Transactions), txCount)
+		return
+	}
+
+	if root := types.DeriveSha(types.Transactions(block.Transactions), trie.NewStackTrie(nil)); root != header.TxHash {
+		info.BlockError = fmt.Sprintf("transactions hash to %s but transactionsRoot is %s", root.Hex(), header.TxHash.Hex())
+		return
+	}
+
+	info.BlockOK = true
+}
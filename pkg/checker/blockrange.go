@@ -0,0 +1,52 @@
+package checker
+
+import "fmt"
+
+// maxHashRange limits the blocks of a BlockRange, every block is requested
+// from every node
+const maxHashRange = 10_000
+
+// BlockRange is an inclusive range of block numbers whose hashes are compared
+// instead of the blocks below head, e.g. to investigate a past incident
+type BlockRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+func (r BlockRange) String() string {
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// Len returns the number of blocks in the range
+func (r BlockRange) Len() uint64 {
+	if r.To < r.From {
+		return 0
+	}
+	return r.To - r.From + 1
+}
+
+func (r BlockRange) validate() error {
+	if r.From > r.To {
+		return fmt.Errorf("invalid block range %s: from block is above to block", r)
+	}
+	if r.Len() > maxHashRange {
+		return fmt.Errorf("block range %s spans %d blocks (max allowed: %d)", r, r.Len(), maxHashRange)
+	}
+	return nil
+}
+
+// blocks returns the block numbers of the range up to head. Blocks above the
+// node's head are skipped, the node's lag is reported by the block gap check.
+func (r BlockRange) blocks(head uint64) []uint64 {
+	if r.From > head {
+		return nil
+	}
+
+	last := min(r.To, head)
+	blocks := make([]uint64, 0, last-r.From+1)
+	for block := r.From; block <= last; block++ {
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
@@ -0,0 +1,99 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// blockTimeSample is the number of blocks the block time is averaged over
+const blockTimeSample = 100
+
+// estimateBlockTime averages the interval between the head block of the node
+// and the block blockTimeSample blocks below it. Averaging over many blocks
+// smooths out chains with irregular block times. The request counts against
+// the global node concurrency limit like any node check.
+func (c *Checker) estimateBlockTime(ctx context.Context, n config.NodeInfo, head uint64) (time.Duration, error) {
+	ctx = withLogger(ctx, c.nodeLogger(n))
+
+	select {
+	case c.nodeSem <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	defer func() { <-c.nodeSem }()
+
+	var err error
+	conn := c.clients.acquire(n.Address)
+	defer func() {
+		c.clients.release(n.Address, conn, err == nil)
+	}()
+	if conn.client == nil {
+		conn.client, err = c.dialNode(ctx, n, &conn.rateLimited)
+		if err != nil {
+			return 0, redactError(err, n.Address)
+		}
+		conn.dialedAt = time.Now()
+	}
+
+	blocks := uint64(min(head, blockTimeSample))
+	if blocks == 0 {
+		return 0, errors.New("chain has no blocks to sample")
+	}
+
+	var newest, oldest *blockHeader
+	newest, err = getBlockHeader(ctx, conn.client, fmt.Sprintf("0x%x", head))
+	if err != nil {
+		return 0, redactError(err, n.Address)
+	}
+	oldest, err = getBlockHeader(ctx, conn.client, fmt.Sprintf("0x%x", head-blocks))
+	if err != nil {
+		return 0, redactError(err, n.Address)
+	}
+
+	// Timestamps have a resolution of one second, so chains with several
+	// blocks per second need the full sample to get a non-zero interval
+	if newest.Time <= oldest.Time {
+		return 0, fmt.Errorf("block timestamps don't increase between blocks %d and %d", head-blocks, head)
+	}
+
+	elapsed := time.Duration(newest.Time-oldest.Time) * time.Second
+	return elapsed / time.Duration(blocks), nil
+}
+
+// blockTimeNode returns the node whose head the block time of the chain is
+// estimated from: the reference node if there is one, otherwise the first
+// node at the chain head
+func (c *Checker) blockTimeNode(result *ChainResult, nodes []config.NodeInfo) (config.NodeInfo, bool) {
+	if result.Reference != nil {
+		return config.NodeInfo{
+			ID:      "reference",
+			Chain:   result.Chain,
+			Address: c.opts.References[result.Chain],
+		}, true
+	}
+
+	for i, node := range result.Nodes {
+		if node.Error == nil && !node.RateLimited && node.BlockNumber == result.MaxBlockNumber {
+			return nodes[i], true
+		}
+	}
+
+	return config.NodeInfo{}, false
+}
+
+// autoBlockGap returns the number of blocks the chain produces in
+// Options.AutoGapWindow at its estimated block time. It is never below gap:
+// the estimate of chains with bursts or pauses in block production can be
+// far off, so the configured gap stays the lower bound.
+func (c *Checker) autoBlockGap(result *ChainResult, gap uint64) uint64 {
+	if result.EstimatedBlockTime <= 0 {
+		return gap
+	}
+
+	auto := uint64(c.opts.AutoGapWindow / result.EstimatedBlockTime)
+	return max(gap, auto)
+}
@@ -0,0 +1,74 @@
+package checker
+
+import (
+	"sync"
+	"time"
+)
+
+// expensiveChecks remembers successful debug and archive checks per node
+// address so that they run at most once per Options.DebugCheckTTL when the
+// Checker is reused. Failed checks are not cached and run again every time.
+type expensiveChecks struct {
+	mu      sync.Mutex
+	entries map[string]*expensiveEntry
+}
+
+type expensiveEntry struct {
+	debugMethod string
+	debugAt     time.Time
+	archiveAt   time.Time
+}
+
+func newExpensiveChecks() *expensiveChecks {
+	return &expensiveChecks{
+		entries: make(map[string]*expensiveEntry),
+	}
+}
+
+// debug returns the method of the debug check that passed within ttl
+func (e *expensiveChecks) debug(address string, ttl time.Duration) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[address]
+	if !ok || entry.debugAt.IsZero() || time.Since(entry.debugAt) >= ttl {
+		return "", false
+	}
+
+	return entry.debugMethod, true
+}
+
+func (e *expensiveChecks) storeDebug(address, method string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry := e.entry(address)
+	entry.debugMethod = method
+	entry.debugAt = time.Now()
+}
+
+// archive reports whether the archive check passed within ttl
+func (e *expensiveChecks) archive(address string, ttl time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[address]
+	return ok && !entry.archiveAt.IsZero() && time.Since(entry.archiveAt) < ttl
+}
+
+func (e *expensiveChecks) storeArchive(address string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.entry(address).archiveAt = time.Now()
+}
+
+// entry returns the entry of address, creating it if needed. e.mu must be held.
+func (e *expensiveChecks) entry(address string) *expensiveEntry {
+	entry, ok := e.entries[address]
+	if !ok {
+		entry = &expensiveEntry{}
+		e.entries[address] = entry
+	}
+	return entry
+}
@@ -0,0 +1,60 @@
+package checker
+
+import "strings"
+
+// Category is a failure class derived from a failure reason
+type Category int
+
+const (
+	CategoryOther Category = iota
+	CategoryConnection
+	CategoryChainID
+	CategoryBlockHash
+)
+
+func (c Category) String() string {
+	switch c {
+	case CategoryConnection:
+		return "connection"
+	case CategoryChainID:
+		return "chain_id"
+	case CategoryBlockHash:
+		return "block_hash"
+	default:
+		return "other"
+	}
+}
+
+// Classify returns the category of a ChainResult.Errors entry or FailedNode.Reason.
+// For failed nodes prefer FailedNode.Code.Category.
+func Classify(reason string) Category {
+	switch {
+	case strings.HasPrefix(reason, "connection error"):
+		return CategoryConnection
+	case strings.HasPrefix(reason, "chain ID mismatch"),
+		strings.HasPrefix(reason, "chain ID split"),
+		strings.HasPrefix(reason, "chain ID changed"),
+		strings.HasPrefix(reason, "genesis hash"),
+		strings.HasPrefix(reason, "chain name mismatch"),
+		strings.HasPrefix(reason, "trusted nodes disagree on chain ID"):
+		return CategoryChainID
+	case strings.HasPrefix(reason, "block hash mismatch"),
+		strings.HasPrefix(reason, "trusted peer hash mismatch"),
+		strings.HasPrefix(reason, "trusted nodes disagree on block hash"),
+		strings.HasPrefix(reason, "excessive tip divergence"),
+		strings.HasPrefix(reason, "reorg detected"):
+		return CategoryBlockHash
+	default:
+		return CategoryOther
+	}
+}
+
+// Categories returns all categories in display order
+func Categories() []Category {
+	return []Category{
+		CategoryConnection,
+		CategoryChainID,
+		CategoryBlockHash,
+		CategoryOther,
+	}
+}
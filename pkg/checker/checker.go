@@ -0,0 +1,2068 @@
+package checker
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+	"golang.org/x/time/rate"
+)
+
+type Options struct {
+	MaxBlockGap       uint64
+	BlockHashCount    int
+	CheckDebugMode    bool
+	AllowSyncing      bool
+	ChainParallelism  int
+	MaxTipHashes      int
+	CheckArchive      bool
+	ArchiveBlock      uint64
+	HashTags          []string
+	MinPeers          uint64
+	Concurrency       int
+	GasPriceTolerance float64
+	CheckTxPool       bool
+	ChainMaxBlockGap  map[string]uint64
+	CheckReorg        bool
+	ReorgDepth        uint64
+	ReorgDelay        time.Duration
+	WarnBlockGap      uint64
+	DebugMethods      []string
+	DebugTracer       string
+	DebugBlockOffset  uint64
+	ScoreWeights      ScoreWeights
+	MinScore          float64
+	CheckNetVersion   bool
+	// FailFast cancels the remaining checks after the first failure. Within
+	// a chain only nodes that can't be checked cancel the other nodes, since
+	// validation failures are only known once all nodes of the chain have
+	// been checked; they cancel the other chains.
+	FailFast          bool
+	BaseFeeTolerance  float64
+	CheckChainName    bool
+	ChainRegistry     map[string]uint64
+	DebugCheckTTL     time.Duration
+	ClientTLS         *config.TLSConfig
+	DeepBlockCheck    bool
+	HashConfirmations uint64
+	CheckGetLogs      bool
+	GetLogsRange      uint64
+	RateLimit         float64
+	DialRetries       int
+	CompareHeaders    bool
+	HashRange         *BlockRange
+	RateLimitedWarn   bool
+	CheckSmokeCall    bool
+	MaxFailedPerChain int
+	MinHealthyRatio   float64
+	CheckGenesis      bool
+	StartJitter       time.Duration
+	Events            EventSink
+	HeadSource        string
+	References        map[string]string
+	DebugWarnOnly     bool
+	ConnectionTTL     time.Duration
+	CheckLiveness     bool
+	LivenessDelay     time.Duration
+	MinHeadAdvance    uint64
+	CustomCmd         string
+	CustomCmdTimeout  time.Duration
+	RequireDiversity  bool
+	MaxRuntime        time.Duration
+	AutoGap           bool
+	AutoGapWindow     time.Duration
+	Gzip              bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxBlockGap:       10,
+		BlockHashCount:    5,
+		CheckDebugMode:    true,
+		AllowSyncing:      false,
+		ChainParallelism:  4,
+		MaxTipHashes:      0,
+		CheckArchive:      false,
+		ArchiveBlock:      1,
+		HashTags:          nil,
+		MinPeers:          0,
+		Concurrency:       8,
+		GasPriceTolerance: 0,
+		CheckTxPool:       false,
+		CheckReorg:        false,
+		ReorgDepth:        2,
+		ReorgDelay:        5 * time.Second,
+		WarnBlockGap:      0,
+		DebugMethods:      []string{"debug_traceBlockByNumber"},
+		DebugTracer:       "callTracer",
+		DebugBlockOffset:  0,
+		ScoreWeights:      DefaultScoreWeights(),
+		MinScore:          0,
+		CheckNetVersion:   false,
+		FailFast:          false,
+		BaseFeeTolerance:  0,
+		CheckChainName:    false,
+		ChainRegistry:     DefaultChainRegistry(),
+		DebugCheckTTL:     0,
+		ClientTLS:         nil,
+		DeepBlockCheck:    false,
+		HashConfirmations: 0,
+		CheckGetLogs:      false,
+		GetLogsRange:      10,
+		RateLimit:         0,
+		DialRetries:       2,
+		CompareHeaders:    false,
+		HashRange:         nil,
+		RateLimitedWarn:   false,
+		CheckSmokeCall:    false,
+		MaxFailedPerChain: 0,
+		MinHealthyRatio:   0,
+		CheckGenesis:      false,
+		StartJitter:       0,
+		Events:            NopEventSink{},
+		HeadSource:        HeadSourceBlockNumber,
+		References:        nil,
+		DebugWarnOnly:     false,
+		ConnectionTTL:     5 * time.Minute,
+		CheckLiveness:     false,
+		LivenessDelay:     10 * time.Second,
+		MinHeadAdvance:    1,
+		CustomCmd:         "",
+		CustomCmdTimeout:  30 * time.Second,
+		RequireDiversity:  false,
+		MaxRuntime:        0,
+		AutoGap:           false,
+		AutoGapWindow:     30 * time.Second,
+		Gzip:              false,
+	}
+}
+
+// Values above these limits are allowed but most likely a mistake
+const (
+	lintMaxBlockHashCount = 1000
+	lintMaxBlockGap       = 100_000
+)
+
+// Validate rejects options that would silently skip checks
+func (o Options) Validate() error {
+	var errs []error
+	if len(o.HashTags) == 0 && o.HashRange == nil && o.BlockHashCount < 1 {
+		errs = append(errs, fmt.Errorf("block hash count must be at least 1, got %d", o.BlockHashCount))
+	}
+	if o.HeadSource != "" && o.HeadSource != HeadSourceBlockNumber && o.HeadSource != HeadSourceLatestBlock {
+		errs = append(errs, fmt.Errorf("unsupported head source %q, expected %s or %s", o.HeadSource, HeadSourceBlockNumber, HeadSourceLatestBlock))
+	}
+	if o.CheckLiveness && o.LivenessDelay <= 0 {
+		errs = append(errs, fmt.Errorf("liveness delay must be positive, got %s", o.LivenessDelay))
+	}
+	if o.MaxFailedPerChain < 0 {
+		errs = append(errs, fmt.Errorf("max failed nodes per chain can't be negative, got %d", o.MaxFailedPerChain))
+	}
+	if o.MinHealthyRatio < 0 || o.MinHealthyRatio > 1 {
+		errs = append(errs, fmt.Errorf("min healthy ratio must be between 0 and 1, got %g", o.MinHealthyRatio))
+	}
+	if o.HashRange != nil {
+		if err := o.HashRange.validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if len(o.HashTags) > 0 {
+			errs = append(errs, errors.New("block range and hash tags can't be combined"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lint returns warnings for option values that are valid but unusually large
+func (o Options) Lint() []string {
+	var warnings []string
+	if len(o.HashTags) == 0 && o.HashRange == nil && o.BlockHashCount > lintMaxBlockHashCount {
+		warnings = append(warnings, fmt.Sprintf("block hash count %d is unusually large, every block is requested from every node", o.BlockHashCount))
+	}
+	if o.MaxBlockGap > lintMaxBlockGap {
+		warnings = append(warnings, fmt.Sprintf("max block gap %d is unusually large and effectively disables the block gap check", o.MaxBlockGap))
+	}
+	return warnings
+}
+
+// NodeResult is the result of checking a node. Address is the configured URL
+// including its secrets so that it identifies the node, print it with
+// config.RedactURL. The secrets are masked when the result is serialized.
+type NodeResult struct {
+	ID               string                 `json:"id"`
+	Chain            string                 `json:"chain"`
+	Address          string                 `json:"address"`
+	ChainID          *big.Int               `json:"chain_id"`
+	NetVersion       string                 `json:"net_version,omitempty"`
+	GenesisHash      *common.Hash           `json:"genesis_hash,omitempty"`
+	BlockNumber      uint64                 `json:"block_number"`
+	BlockGap         uint64                 `json:"block_gap"`
+	BlockOffset      int64                  `json:"block_offset"`
+	BlockHashes      map[uint64]common.Hash `json:"block_hashes"`
+	BlockHeaders     map[uint64]HeaderInfo  `json:"block_headers,omitempty"`
+	TagBlocks        map[string]BlockRef    `json:"tag_blocks,omitempty"`
+	DebugOK          bool                   `json:"debug_ok"`
+	DebugMethod      string                 `json:"debug_method,omitempty"`
+	Syncing          bool                   `json:"syncing"`
+	Reorged          bool                   `json:"reorged"`
+	HeadAdvance      *uint64                `json:"head_advance,omitempty"`
+	PeerCount        *uint64                `json:"peer_count"`
+	ClientVersion    string                 `json:"client_version,omitempty"`
+	ClientName       string                 `json:"client_name,omitempty"`
+	ClientSemver     string                 `json:"client_semver,omitempty"`
+	GasPrice         *big.Int               `json:"gas_price,omitempty"`
+	BaseFee          *big.Int               `json:"base_fee,omitempty"`
+	TxPool           *TxPoolStatus          `json:"txpool,omitempty"`
+	Logs             *LogsStatus            `json:"logs,omitempty"`
+	SmokeCall        *SmokeCallStatus       `json:"smoke_call,omitempty"`
+	CustomCheck      *CustomCheckStatus     `json:"custom_check,omitempty"`
+	SyncStatus       *SyncStatus            `json:"sync_status,omitempty"`
+	TrustedBlockHash *common.Hash           `json:"trusted_block_hash,omitempty"`
+	PinnedHashes     map[uint64]common.Hash `json:"pinned_hashes,omitempty"`
+	ArchiveOK        bool                   `json:"archive_ok"`
+	ArchiveError     string                 `json:"archive_error,omitempty"`
+	BlockOK          bool                   `json:"block_ok"`
+	BlockError       string                 `json:"block_error,omitempty"`
+	RateLimited      bool                   `json:"rate_limited,omitempty"`
+	Timings          Timings                `json:"timings"`
+	Warnings         []string               `json:"warnings,omitempty"`
+	Trusted          bool                   `json:"trusted,omitempty"`
+	Priority         string                 `json:"priority,omitempty"`
+	CachedChecks     []string               `json:"cached_checks,omitempty"`
+	HashMismatch     bool                   `json:"hash_mismatch"`
+	Health           float64                `json:"health_score"`
+	Error            error                  `json:"-"`
+}
+
+// MarshalJSON encodes the node result with Error as a string and the
+// secrets of Address masked
+func (n NodeResult) MarshalJSON() ([]byte, error) {
+	type nodeResult NodeResult
+
+	var errMsg string
+	if n.Error != nil {
+		errMsg = n.Error.Error()
+	}
+	n.Address = config.RedactURL(n.Address)
+
+	return json.Marshal(struct {
+		nodeResult
+		Error string `json:"error,omitempty"`
+	}{
+		nodeResult: nodeResult(n),
+		Error:      errMsg,
+	})
+}
+
+// Timings holds the duration of each RPC call made while checking a node
+type Timings struct {
+	Dial          time.Duration            `json:"dial"`
+	ChainID       time.Duration            `json:"chain_id"`
+	Genesis       time.Duration            `json:"genesis"`
+	BlockNumber   time.Duration            `json:"block_number"`
+	SyncStatus    time.Duration            `json:"sync_status"`
+	PeerCount     time.Duration            `json:"peer_count"`
+	NetVersion    time.Duration            `json:"net_version"`
+	ClientVersion time.Duration            `json:"client_version"`
+	GasPrice      time.Duration            `json:"gas_price"`
+	BaseFee       time.Duration            `json:"base_fee"`
+	Blocks        map[uint64]time.Duration `json:"blocks"`
+	Tags          map[string]time.Duration `json:"tags,omitempty"`
+	Debug         time.Duration            `json:"debug"`
+	Archive       time.Duration            `json:"archive"`
+	DeepBlock     time.Duration            `json:"deep_block"`
+	TxPool        time.Duration            `json:"txpool"`
+	GetLogs       time.Duration            `json:"get_logs"`
+	SmokeCall     time.Duration            `json:"smoke_call"`
+	CustomCheck   time.Duration            `json:"custom_check"`
+	Reorg         time.Duration            `json:"reorg"`
+	Liveness      time.Duration            `json:"liveness"`
+	Total         time.Duration            `json:"total"`
+}
+
+// SyncStatus holds the sync progress reported by eth_syncing
+type SyncStatus struct {
+	StartingBlock uint64 `json:"starting_block"`
+	CurrentBlock  uint64 `json:"current_block"`
+	HighestBlock  uint64 `json:"highest_block"`
+}
+
+// BlockRef identifies a block by number and hash
+type BlockRef struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+type ChainResult struct {
+	Chain              string                                  `json:"chain"`
+	Nodes              []NodeResult                            `json:"nodes"`
+	ExpectedChainID    *big.Int                                `json:"expected_chain_id"`
+	MaxBlockNumber     uint64                                  `json:"max_block_number"`
+	EstimatedBlockTime time.Duration                           `json:"estimated_block_time,omitempty"`
+	TrustedBlock       *BlockRef                               `json:"trusted_block,omitempty"`
+	Reference          *NodeResult                             `json:"reference,omitempty"`
+	HashConsensus      map[uint64]HashConsensusInfo            `json:"hash_consensus"`
+	TagHashConsensus   map[string]map[uint64]HashConsensusInfo `json:"tag_hash_consensus,omitempty"`
+	Health             float64                                 `json:"health_score"`
+	FailedNodes        []FailedNode                            `json:"failed_nodes"`
+	Errors             []string                                `json:"errors"`
+	Warnings           []string                                `json:"warnings,omitempty"`
+	Passed             bool                                    `json:"passed"`
+
+	// Degraded chains passed although some of their nodes failed, see
+	// Options.MaxFailedPerChain and Options.MinHealthyRatio
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// NodeLatency is the total check time of a node
+type NodeLatency struct {
+	ID      string        `json:"id"`
+	Chain   string        `json:"chain"`
+	Address string        `json:"address"`
+	Total   time.Duration `json:"total"`
+}
+
+// MarshalJSON encodes the latency with the secrets of Address masked
+func (n NodeLatency) MarshalJSON() ([]byte, error) {
+	type nodeLatency NodeLatency
+
+	n.Address = config.RedactURL(n.Address)
+	return json.Marshal(nodeLatency(n))
+}
+
+// SlowestNodes returns up to n nodes of all chains with the longest total
+// check time, slowest first. Failed nodes are included.
+func (r *CheckResult) SlowestNodes(n int) []NodeLatency {
+	if n <= 0 {
+		return nil
+	}
+
+	var nodes []NodeLatency
+	for _, chainResult := range r.ChainResults {
+		for _, node := range chainResult.Nodes {
+			nodes = append(nodes, NodeLatency{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Total:   node.Timings.Total,
+			})
+		}
+	}
+
+	slices.SortStableFunc(nodes, func(a, b NodeLatency) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+
+	return nodes[:min(n, len(nodes))]
+}
+
+// HashConsensusInfo describes how the nodes of a chain voted on a block hash.
+// MajorityHash is nil when several hashes tie for the most votes.
+type HashConsensusInfo struct {
+	MajorityHash  *common.Hash             `json:"majority_hash"`
+	MajorityVotes int                      `json:"majority_votes"`
+	Dissenting    map[common.Hash][]string `json:"dissenting,omitempty"`
+}
+
+type CheckResult struct {
+	ChainResults []ChainResult `json:"chain_results"`
+	FailedNodes  []FailedNode  `json:"failed_nodes"`
+	Passed       bool          `json:"passed"`
+}
+
+// Summary holds the totals of a check run
+type Summary struct {
+	TotalChains int            `json:"total_chains"`
+	TotalNodes  int            `json:"total_nodes"`
+	PassedNodes int            `json:"passed_nodes"`
+	FailedNodes int            `json:"failed_nodes"`
+	WarnedNodes int            `json:"warned_nodes"`
+	RateLimited int            `json:"rate_limited_nodes"`
+	Failures    map[string]int `json:"failures"`
+}
+
+// Summary counts chains, nodes and failures. A node that failed several
+// checks is counted once in FailedNodes, but each reason is counted in Failures.
+// Nodes with warnings are counted in WarnedNodes whether they passed or not,
+// rate limited nodes are also counted in RateLimited.
+func (r *CheckResult) Summary() Summary {
+	summary := Summary{
+		TotalChains: len(r.ChainResults),
+		Failures:    make(map[string]int),
+	}
+
+	for _, chainResult := range r.ChainResults {
+		summary.TotalNodes += len(chainResult.Nodes)
+
+		for _, node := range chainResult.Nodes {
+			if len(node.Warnings) > 0 {
+				summary.WarnedNodes++
+			}
+			if node.RateLimited {
+				summary.RateLimited++
+			}
+		}
+
+		failed := make(map[string]bool)
+		for _, fn := range chainResult.FailedNodes {
+			failed[fn.Address] = true
+			summary.Failures[fn.Code.Category().String()]++
+		}
+		summary.FailedNodes += len(failed)
+
+		for _, reason := range chainResult.Errors {
+			summary.Failures[Classify(reason).String()]++
+		}
+	}
+
+	summary.PassedNodes = summary.TotalNodes - summary.FailedNodes
+
+	return summary
+}
+
+type FailedNode struct {
+	ID      string     `json:"id"`
+	Chain   string     `json:"chain"`
+	Address string     `json:"address"`
+	Code    ReasonCode `json:"code"`
+	Reason  string     `json:"reason"`
+
+	// Priority is the priority of the node's connector, see config.Connector
+	Priority string `json:"priority,omitempty"`
+}
+
+// MarshalJSON encodes the failure with the secrets of Address masked
+func (f FailedNode) MarshalJSON() ([]byte, error) {
+	type failedNode FailedNode
+
+	f.Address = config.RedactURL(f.Address)
+	return json.Marshal(failedNode(f))
+}
+
+// Checker validates the nodes of a config. It is safe for concurrent use;
+// node checks started by Check, CheckChain and CheckNode share the
+// Options.Concurrency limit.
+type Checker struct {
+	cfg    *config.Config
+	opts   Options
+	logger *slog.Logger
+
+	// nodeSem limits the number of simultaneous node checks across all chains
+	nodeSem chan struct{}
+
+	// expensive caches passed debug and archive checks across runs
+	expensive *expensiveChecks
+
+	// chainIDs holds the last chain ID of every node across runs
+	chainIDs *chainIDHistory
+
+	// clients keeps the dialed clients of nodes across runs
+	clients *clientPool
+
+	// httpClients holds one HTTP client per TLS configuration
+	httpClientsMu sync.Mutex
+	httpClients   map[config.TLSConfig]*http.Client
+
+	// limiter is shared by the HTTP requests to all nodes, nil if
+	// Options.RateLimit is not set
+	limiter *rate.Limiter
+}
+
+// New creates a Checker for the nodes and chain settings of cfg
+func New(cfg *config.Config, opts Options, logger *slog.Logger) *Checker {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// Keep secrets embedded in node URLs out of the logs
+	var urls []string
+	for _, node := range cfg.GetAllNodes() {
+		urls = append(urls, node.Address)
+	}
+	for _, chainCfg := range cfg.Chains {
+		if chainCfg.TrustedPeer != "" {
+			urls = append(urls, chainCfg.TrustedPeer)
+		}
+	}
+	for _, address := range opts.References {
+		urls = append(urls, address)
+	}
+
+	if opts.Events == nil {
+		opts.Events = NopEventSink{}
+	}
+
+	// Requests are spread out evenly, without bursts
+	var limiter *rate.Limiter
+	if opts.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RateLimit), 1)
+	}
+
+	return &Checker{
+		cfg:         cfg,
+		opts:        opts,
+		logger:      slog.New(newRedactHandler(logger.Handler(), urls)),
+		nodeSem:     make(chan struct{}, concurrency),
+		expensive:   newExpensiveChecks(),
+		chainIDs:    newChainIDHistory(),
+		clients:     newClientPool(opts.ConnectionTTL),
+		httpClients: make(map[config.TLSConfig]*http.Client),
+		limiter:     limiter,
+	}
+}
+
+// Close closes the node clients kept for reuse between runs. The Checker
+// can still be used afterwards, nodes are dialed again.
+func (c *Checker) Close() {
+	c.clients.close()
+}
+
+// maxBlockGap returns the block gap allowed for chain. A --chain-gap value
+// takes precedence over the chain config, which takes precedence over the
+// global default.
+func (c *Checker) maxBlockGap(chain string) uint64 {
+	if gap, ok := c.opts.ChainMaxBlockGap[chain]; ok {
+		return gap
+	}
+	if gap := c.cfg.Chains[chain].MaxBlockGap; gap != nil {
+		return *gap
+	}
+	return c.opts.MaxBlockGap
+}
+
+// blockHashCount returns the number of recent blocks compared for chain
+func (c *Checker) blockHashCount(chain string) int {
+	if count := c.cfg.Chains[chain].BlockHashCount; count != nil {
+		return *count
+	}
+	return c.opts.BlockHashCount
+}
+
+// hashBlocks returns the numbers of the blocks whose hashes are compared for
+// a node at head: count blocks going back from head - confirmations. Blocks
+// below genesis are left out.
+func hashBlocks(head, confirmations uint64, count int) []uint64 {
+	if head < confirmations || count <= 0 {
+		return nil
+	}
+
+	first := head - confirmations
+	blocks := make([]uint64, 0, count)
+	for i := uint64(0); i < uint64(count) && i <= first; i++ {
+		blocks = append(blocks, first-i)
+	}
+
+	return blocks
+}
+
+// Check runs all checks and aggregates the results of every chain, sorted
+// by chain name. Chains are checked in parallel, see CheckStream.
+func (c *Checker) Check(ctx context.Context) (*CheckResult, error) {
+	result := &CheckResult{
+		ChainResults: make([]ChainResult, 0),
+		FailedNodes:  make([]FailedNode, 0),
+		Passed:       true,
+	}
+
+	for chainResult := range c.CheckStream(ctx) {
+		result.ChainResults = append(result.ChainResults, chainResult)
+	}
+
+	// Chains finish in any order, sort them for stable output
+	slices.SortFunc(result.ChainResults, func(a, b ChainResult) int {
+		return strings.Compare(a.Chain, b.Chain)
+	})
+
+	for _, chainResult := range result.ChainResults {
+		if !chainResult.Passed {
+			result.Passed = false
+		}
+		result.FailedNodes = append(result.FailedNodes, chainResult.FailedNodes...)
+	}
+
+	return result, nil
+}
+
+// CheckStream checks all chains and sends each ChainResult to the returned
+// channel as soon as the chain is done. At most Options.ChainParallelism
+// chains are checked at the same time. The channel is closed when all
+// chains have been checked. With Options.FailFast, the first failed or
+// degraded chain cancels the remaining checks and their nodes fail with
+// "check cancelled".
+// Checks still running after Options.MaxRuntime are aborted the same way.
+func (c *Checker) CheckStream(ctx context.Context) <-chan ChainResult {
+	nodesByChain := c.cfg.GetNodesByChain()
+
+	prog := &progress{}
+	for _, nodes := range nodesByChain {
+		prog.total += int64(len(nodes))
+	}
+
+	parallelism := c.opts.ChainParallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	results := make(chan ChainResult)
+	sem := make(chan struct{}, parallelism)
+
+	// Abort all checks once the max runtime has passed
+	stop := func() {}
+	if c.opts.MaxRuntime > 0 {
+		ctx, stop = context.WithTimeoutCause(ctx, c.opts.MaxRuntime, errMaxRuntime)
+	}
+
+	// In fail-fast mode the first chain with a failed node cancels all other
+	// chains
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	for chain, nodes := range nodesByChain {
+		wg.Add(1)
+		go func(chain string, nodes []config.NodeInfo) {
+			defer wg.Done()
+
+			// Chains not started before cancellation report all nodes as cancelled
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+
+			chainResult := c.checkChain(ctx, chain, nodes, prog)
+			if c.opts.FailFast && (!chainResult.Passed || chainResult.Degraded) {
+				cancel()
+			}
+			results <- chainResult
+		}(chain, nodes)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		stop()
+		close(results)
+	}()
+
+	return results
+}
+
+// CheckNode checks a single node without comparing it to other nodes. Only
+// failures of the node itself, such as connection errors, are recorded in
+// the result; use CheckChain for cross-node validation. If ctx is cancelled
+// before the check starts, the node fails with "check cancelled".
+func (c *Checker) CheckNode(ctx context.Context, n config.NodeInfo) NodeResult {
+	select {
+	case c.nodeSem <- struct{}{}:
+	case <-ctx.Done():
+		return NodeResult{ID: n.ID, Chain: n.Chain, Address: n.Address, Error: errCheckCancelled}
+	}
+	defer func() { <-c.nodeSem }()
+
+	info := c.checkNode(ctx, n, nil)
+	c.opts.Events.OnNodeChecked(info)
+
+	return info
+}
+
+// CheckChain checks the given nodes of chain in parallel and validates them
+// against each other and the chain settings of the config. If ctx is
+// cancelled, nodes already checked keep their results and the others fail
+// with "check cancelled".
+func (c *Checker) CheckChain(ctx context.Context, chain string, nodes []config.NodeInfo) ChainResult {
+	return c.checkChain(ctx, chain, nodes, &progress{total: int64(len(nodes))})
+}
+
+// progress counts the checked nodes of one Check or CheckChain call for
+// verbose logging
+type progress struct {
+	checked atomic.Int64
+	total   int64
+}
+
+func (c *Checker) checkChain(ctx context.Context, chain string, nodes []config.NodeInfo, prog *progress) ChainResult {
+	result := ChainResult{
+		Chain:       chain,
+		Nodes:       make([]NodeResult, len(nodes)),
+		FailedNodes: make([]FailedNode, 0),
+		Errors:      make([]string, 0),
+		Passed:      true,
+
+		HashConsensus: make(map[uint64]HashConsensusInfo),
+	}
+
+	// Get finalized block from the trusted peer. The request counts against
+	// the global node concurrency limit like any node check.
+	if peer := c.cfg.Chains[chain].TrustedPeer; peer != "" {
+		var trustedBlock *BlockRef
+		var err error
+		select {
+		case c.nodeSem <- struct{}{}:
+			trustedBlock, err = getTrustedBlock(ctx, peer)
+			<-c.nodeSem
+		case <-ctx.Done():
+			err = errCheckCancelled
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("trusted peer error: %v", redactError(err, peer)))
+			result.Passed = false
+		}
+		result.TrustedBlock = trustedBlock
+	}
+
+	// Check the reference node of the chain. It replaces the trusted nodes
+	// and the majority as the baseline, so the chain can't be validated
+	// without it.
+	if address, ok := c.opts.References[chain]; ok {
+		reference := NodeResult{ID: "reference", Chain: chain, Address: address, Error: errCheckCancelled}
+		select {
+		case c.nodeSem <- struct{}{}:
+			reference = c.checkNode(ctx, config.NodeInfo{
+				ID:               "reference",
+				Chain:            chain,
+				Address:          address,
+				DebugUnsupported: true,
+			}, result.TrustedBlock)
+			<-c.nodeSem
+		case <-ctx.Done():
+		}
+		if reference.Error != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("reference node error: %v", reference.Error))
+			result.Passed = false
+		} else {
+			result.Reference = &reference
+		}
+	}
+
+	// In fail-fast mode the first node that can't be checked cancels the others
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := make([]bool, len(nodes))
+
+	// Gather info from all nodes in parallel
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(idx int, n config.NodeInfo) {
+			defer wg.Done()
+
+			// Spread out the start of the node checks so that nodes behind
+			// a shared provider are not all hit at once
+			if c.opts.StartJitter > 0 {
+				select {
+				case <-time.After(rand.N(c.opts.StartJitter)):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case c.nodeSem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-c.nodeSem }()
+
+			info := c.checkNode(ctx, n, result.TrustedBlock)
+
+			c.logger.Debug("node checked",
+				"node", n.ID,
+				"chain", n.Chain,
+				"progress", fmt.Sprintf("%d/%d", prog.checked.Add(1), prog.total))
+			c.opts.Events.OnNodeChecked(info)
+
+			// Results of checks interrupted by cancellation are incomplete
+			mu.Lock()
+			if ctx.Err() == nil {
+				result.Nodes[idx] = info
+				done[idx] = true
+			}
+			mu.Unlock()
+
+			if c.opts.FailFast && info.Error != nil {
+				cancel()
+			}
+		}(i, node)
+	}
+
+	// Stop waiting for in-flight checks when the context is cancelled
+	waitDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waitDone)
+	}()
+
+	select {
+	case <-waitDone:
+	case <-ctx.Done():
+	}
+
+	// Nodes of checks aborted by Options.MaxRuntime say so
+	cancelErr := errCheckCancelled
+	if errors.Is(context.Cause(ctx), errMaxRuntime) {
+		cancelErr = errMaxRuntime
+	}
+
+	mu.Lock()
+	for i, n := range nodes {
+		if !done[i] {
+			result.Nodes[i] = NodeResult{
+				ID:      n.ID,
+				Chain:   n.Chain,
+				Address: n.Address,
+				Error:   cancelErr,
+			}
+		}
+	}
+	mu.Unlock()
+
+	// Determine expected chain ID from the reference or trusted nodes, or by
+	// majority vote if there are none or they disagree
+	trusted := result.baselineNodes()
+	expectedChainID, ok := determineExpectedChainID(trusted)
+	if !ok {
+		result.Errors = append(result.Errors, fmt.Sprintf("trusted nodes disagree on chain ID: %s", formatChainIDVotes(trusted)))
+		result.Passed = false
+	}
+	if expectedChainID == nil || !ok {
+		voters := chainIDVoters(result.Nodes, nodes)
+		expectedChainID, ok = determineExpectedChainID(voters)
+		if !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("chain ID split: %s", formatChainIDVotes(voters)))
+			result.Passed = false
+		}
+	}
+	result.ExpectedChainID = expectedChainID
+	if c.opts.CheckChainName {
+		c.checkChainName(&result)
+	}
+
+	// Find max block number, of the reference or trusted nodes if there are
+	// any. Nodes ahead of them have no gap.
+	headNodes := result.Nodes
+	if len(trusted) > 0 {
+		headNodes = trusted
+	}
+	for _, node := range headNodes {
+		if node.Error == nil && node.BlockNumber > result.MaxBlockNumber {
+			result.MaxBlockNumber = node.BlockNumber
+		}
+	}
+	for i := range result.Nodes {
+		if result.Nodes[i].Error != nil {
+			continue
+		}
+		result.Nodes[i].BlockOffset = int64(result.Nodes[i].BlockNumber) - int64(result.MaxBlockNumber)
+		if result.Nodes[i].BlockNumber < result.MaxBlockNumber {
+			result.Nodes[i].BlockGap = result.MaxBlockNumber - result.Nodes[i].BlockNumber
+		}
+	}
+
+	// Estimate the block time at the chain head, which scales the block gap
+	// with Options.AutoGap
+	if node, ok := c.blockTimeNode(&result, nodes); ok && ctx.Err() == nil {
+		blockTime, err := c.estimateBlockTime(ctx, node, result.MaxBlockNumber)
+		if err != nil {
+			c.logger.Warn("failed to estimate block time",
+				"chain", chain,
+				"node", node.ID,
+				"error", err)
+		} else {
+			result.EstimatedBlockTime = blockTime
+			c.logger.Info("estimated block time",
+				"chain", chain,
+				"node", node.ID,
+				"block_time", blockTime)
+		}
+	}
+
+	// Heads that don't advance are only a failure if the chain does
+	advancing := c.opts.CheckLiveness && c.chainAdvancing(result.Nodes)
+
+	// Validate all nodes
+	for i, node := range result.Nodes {
+		if errors.Is(node.Error, errCheckCancelled) || errors.Is(node.Error, errMaxRuntime) {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonCancelled,
+				Reason:  node.Error.Error(),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Failed calls of rate limited nodes say nothing about their health
+		if node.RateLimited {
+			if c.opts.RateLimitedWarn {
+				result.Nodes[i].Warnings = append(result.Nodes[i].Warnings, "rate limited by provider, checks are incomplete")
+				continue
+			}
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonRateLimited,
+				Reason:  "rate limited by provider",
+			})
+			result.Passed = false
+			continue
+		}
+
+		if node.Error != nil {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonConnection,
+				Reason:  fmt.Sprintf("connection error: %v", node.Error),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check that the chain ID did not change since the previous run of
+		// a reused Checker
+		if previous := c.chainIDs.swap(node.Address, node.ChainID); previous != nil && previous.Cmp(node.ChainID) != 0 {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonChainIDChanged,
+				Reason:  fmt.Sprintf("chain ID changed from %s to %s between cycles", previous, node.ChainID),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check chain ID against the accepted chain IDs of the node, if any
+		accepted := nodes[i].AcceptedChainIDs
+		if len(accepted) > 0 && !acceptsChainID(accepted, node.ChainID) {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonChainIDMismatch,
+				Reason:  fmt.Sprintf("chain ID mismatch: expected one of %s, got %s", formatChainIDs(accepted), node.ChainID),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check chain ID (only if we have expected chain ID)
+		if len(accepted) == 0 && result.ExpectedChainID != nil && node.ChainID.Cmp(result.ExpectedChainID) != 0 {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonChainIDMismatch,
+				Reason:  fmt.Sprintf("chain ID mismatch: expected %s, got %s", result.ExpectedChainID.String(), node.ChainID.String()),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check net_version against the chain ID (unknown net version is not a failure)
+		if c.opts.CheckNetVersion && node.NetVersion != "" {
+			netVersion, ok := parseNetVersion(node.NetVersion)
+			if !ok || netVersion.Cmp(node.ChainID) != 0 {
+				result.FailedNodes = append(result.FailedNodes, FailedNode{
+					ID:      node.ID,
+					Chain:   node.Chain,
+					Address: node.Address,
+					Code:    ReasonNetVersionMismatch,
+					Reason:  fmt.Sprintf("net_version mismatch: chain ID %s, net_version %s", node.ChainID.String(), node.NetVersion),
+				})
+				result.Passed = false
+				continue
+			}
+		}
+
+		// Check syncing status
+		if !c.opts.AllowSyncing && node.Syncing {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonSyncing,
+				Reason:  "node is still syncing",
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check peer count (unknown peer count is not a failure)
+		if c.opts.MinPeers > 0 && node.PeerCount != nil && *node.PeerCount < c.opts.MinPeers {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonPeerCount,
+				Reason:  fmt.Sprintf("peer count too low: %d < %d", *node.PeerCount, c.opts.MinPeers),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check that the head advanced while the chain produced blocks. A
+		// stuck head usually means a cache in front of the node.
+		if c.opts.CheckLiveness && advancing && node.HeadAdvance != nil && *node.HeadAdvance < c.opts.MinHeadAdvance {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonHeadStuck,
+				Reason:  fmt.Sprintf("head stuck at block %d: advanced %d blocks in %s (min expected: %d)", node.BlockNumber, *node.HeadAdvance, c.opts.LivenessDelay, c.opts.MinHeadAdvance),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check block gap (per-node override takes precedence)
+		maxBlockGap := c.maxBlockGap(chain)
+		if c.opts.AutoGap {
+			maxBlockGap = c.autoBlockGap(&result, maxBlockGap)
+		}
+		if nodes[i].MaxBlockGap != nil {
+			maxBlockGap = *nodes[i].MaxBlockGap
+		}
+		if node.BlockGap > maxBlockGap {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonBlockGap,
+				Reason:  fmt.Sprintf("block gap too large: %d blocks behind (max allowed: %d)", node.BlockGap, maxBlockGap),
+			})
+			result.Passed = false
+			continue
+		}
+		if c.opts.WarnBlockGap > 0 && node.BlockGap > c.opts.WarnBlockGap {
+			result.Nodes[i].Warnings = append(result.Nodes[i].Warnings,
+				fmt.Sprintf("block gap near limit: %d blocks behind (warn at: %d, max allowed: %d)", node.BlockGap, c.opts.WarnBlockGap, maxBlockGap))
+		}
+
+		// Check block hash stability below the head
+		if c.opts.CheckReorg && node.Reorged {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonReorg,
+				Reason:  fmt.Sprintf("reorg detected at depth %d", c.opts.ReorgDepth),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check debug mode. In warn-only mode a missing debug namespace is
+		// reported without failing the node.
+		if c.opts.CheckDebugMode && !node.DebugOK {
+			reason := fmt.Sprintf("debug mode not available (%s not supported)", strings.Join(c.opts.DebugMethods, ", "))
+			if c.opts.DebugWarnOnly {
+				result.Nodes[i].Warnings = append(result.Nodes[i].Warnings, reason)
+			} else {
+				result.FailedNodes = append(result.FailedNodes, FailedNode{
+					ID:      node.ID,
+					Chain:   node.Chain,
+					Address: node.Address,
+					Code:    ReasonDebugUnavailable,
+					Reason:  reason,
+				})
+				result.Passed = false
+				continue
+			}
+		}
+
+		// Check transaction pool
+		if c.opts.CheckTxPool && node.TxPool == nil {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonTxPoolUnavailable,
+				Reason:  "txpool not available (txpool_status and txpool_content not supported)",
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check eth_getLogs
+		if c.opts.CheckGetLogs && node.Logs != nil && node.Logs.Error != "" {
+			reason := fmt.Sprintf("eth_getLogs failed: %s", node.Logs.Error)
+			if node.Logs.Limited {
+				reason = fmt.Sprintf("eth_getLogs rejected %d block range: %s", node.Logs.ToBlock-node.Logs.FromBlock+1, node.Logs.Error)
+			}
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonGetLogsUnavailable,
+				Reason:  reason,
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check the eth_call of the chain
+		if smokeCall := c.cfg.Chains[node.Chain].SmokeCall; c.opts.CheckSmokeCall && smokeCall != nil && node.SmokeCall != nil {
+			if reason := smokeCallFailure(node.SmokeCall, *smokeCall); reason != "" {
+				result.FailedNodes = append(result.FailedNodes, FailedNode{
+					ID:      node.ID,
+					Chain:   node.Chain,
+					Address: node.Address,
+					Code:    ReasonSmokeCallFailed,
+					Reason:  reason,
+				})
+				result.Passed = false
+				continue
+			}
+		}
+
+		// Check the external check command
+		if node.CustomCheck != nil && !node.CustomCheck.OK {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonCustomCheckFailed,
+				Reason:  "custom check failed: " + node.CustomCheck.Error,
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check archive state
+		if c.opts.CheckArchive && !node.ArchiveOK {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonArchiveUnavailable,
+				Reason:  fmt.Sprintf("archive state not available at block %d: %s", c.opts.ArchiveBlock, node.ArchiveError),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check block consistency
+		if c.opts.DeepBlockCheck && node.BlockError != "" {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonBlockInconsistent,
+				Reason:  fmt.Sprintf("inconsistent block %d: %s", node.BlockNumber, node.BlockError),
+			})
+			result.Passed = false
+			continue
+		}
+
+		// Check against trusted peer finalized block
+		if result.TrustedBlock != nil {
+			var code ReasonCode
+			var reason string
+			if node.TrustedBlockHash == nil {
+				code = ReasonTrustedPeerUnavailable
+				reason = fmt.Sprintf("failed to get trusted peer finalized block %d", result.TrustedBlock.Number)
+			} else if *node.TrustedBlockHash != result.TrustedBlock.Hash {
+				code = ReasonTrustedPeerMismatch
+				reason = fmt.Sprintf("trusted peer hash mismatch at block %d: got %s, expected %s", result.TrustedBlock.Number, node.TrustedBlockHash.Hex(), result.TrustedBlock.Hash.Hex())
+			}
+
+			if reason != "" {
+				result.FailedNodes = append(result.FailedNodes, FailedNode{
+					ID:      node.ID,
+					Chain:   node.Chain,
+					Address: node.Address,
+					Code:    code,
+					Reason:  reason,
+				})
+				result.Passed = false
+				continue
+			}
+		}
+
+		// Check against the block hashes pinned in the config, which take
+		// precedence over any consensus of the nodes
+		if reason, ok := pinnedHashMismatch(node, nodes[i].PinnedHashes); ok {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonPinnedHashMismatch,
+				Reason:  reason,
+			})
+			result.Passed = false
+			continue
+		}
+	}
+
+	// Check genesis block consistency
+	if c.opts.CheckGenesis {
+		c.checkGenesis(&result)
+	}
+
+	// Check gas price consistency
+	if c.opts.GasPriceTolerance > 0 {
+		c.checkGasPrices(&result)
+	}
+
+	// Check base fee consistency
+	if c.opts.BaseFeeTolerance > 0 {
+		c.checkBaseFees(&result)
+	}
+
+	// Check block hashes consistency
+	if len(c.opts.HashTags) > 0 {
+		c.checkTagHashes(&result)
+	} else {
+		c.checkBlockHashes(&result)
+	}
+
+	// Check that the chain doesn't depend on a single provider
+	c.checkProviderDiversity(&result, nodes)
+
+	// Score nodes once all other checks are done
+	c.scoreNodes(&result)
+
+	// Primary nodes fail with elevated severity, fallback nodes only warn
+	applyPriorities(&result)
+
+	// Tolerate failed nodes if enough nodes are healthy
+	c.applyQuorum(&result)
+
+	c.opts.Events.OnChainComplete(result)
+
+	return result
+}
+
+// determineExpectedChainID returns the chain ID reported by the most nodes.
+// If several chain IDs share the highest vote count there is no majority:
+// the chain ID of the first responding node among them is returned with
+// false. It returns nil and true when no node responded.
+func determineExpectedChainID(nodes []NodeResult) (*big.Int, bool) {
+	votes := countChainIDVotes(nodes)
+
+	maxVotes := 0
+	for _, count := range votes {
+		maxVotes = max(maxVotes, count)
+	}
+
+	// Collect chain IDs with the most votes in node order
+	var leaders []*big.Int
+	for _, node := range nodes {
+		if node.Error != nil || node.ChainID == nil || votes[node.ChainID.String()] != maxVotes {
+			continue
+		}
+		if !slices.ContainsFunc(leaders, func(id *big.Int) bool { return id.Cmp(node.ChainID) == 0 }) {
+			leaders = append(leaders, node.ChainID)
+		}
+	}
+
+	if len(leaders) == 0 {
+		return nil, true
+	}
+
+	return leaders[0], len(leaders) == 1
+}
+
+// chainIDVoters returns the node results that vote on the expected chain ID
+// of the chain. Nodes with accepted chain IDs are checked against those and
+// don't vote.
+func chainIDVoters(results []NodeResult, nodes []config.NodeInfo) []NodeResult {
+	voters := make([]NodeResult, 0, len(results))
+	for i, node := range results {
+		if len(nodes[i].AcceptedChainIDs) == 0 {
+			voters = append(voters, node)
+		}
+	}
+	return voters
+}
+
+// acceptsChainID reports whether id is one of the accepted chain IDs
+func acceptsChainID(accepted []uint64, id *big.Int) bool {
+	return id.IsUint64() && slices.Contains(accepted, id.Uint64())
+}
+
+// formatChainIDs formats chain IDs as a comma separated list
+func formatChainIDs(ids []uint64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseNetVersion parses a net_version result, which is usually a decimal
+// string but is hex encoded by some clients
+func parseNetVersion(version string) (*big.Int, bool) {
+	if hex, ok := strings.CutPrefix(version, "0x"); ok {
+		return new(big.Int).SetString(hex, 16)
+	}
+	return new(big.Int).SetString(version, 10)
+}
+
+// countChainIDVotes counts responding nodes per chain ID
+func countChainIDVotes(nodes []NodeResult) map[string]int {
+	votes := make(map[string]int)
+	for _, node := range nodes {
+		if node.Error == nil && node.ChainID != nil {
+			votes[node.ChainID.String()]++
+		}
+	}
+	return votes
+}
+
+// formatChainIDVotes describes how many nodes reported each chain ID,
+// e.g. "1 (2 nodes), 56 (2 nodes)"
+func formatChainIDVotes(nodes []NodeResult) string {
+	votes := countChainIDVotes(nodes)
+
+	parts := make([]string, 0, len(votes))
+	for chainID, count := range votes {
+		parts = append(parts, fmt.Sprintf("%s (%d nodes)", chainID, count))
+	}
+	slices.Sort(parts)
+
+	return strings.Join(parts, ", ")
+}
+
+// blockHeader is a minimal block header for getting hash and the fields
+// compared on hash mismatches
+type blockHeader struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	StateRoot  common.Hash    `json:"stateRoot"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+}
+
+var errBlockNotFound = errors.New("block not found")
+
+// errCheckCancelled marks nodes whose check did not finish before the context
+// was cancelled
+var errCheckCancelled = errors.New("check cancelled")
+
+// errMaxRuntime marks nodes whose check was aborted by Options.MaxRuntime
+var errMaxRuntime = errors.New("aborted: max runtime exceeded")
+
+// getBlockHeader fetches a block header by hex number or tag (e.g. "finalized")
+func getBlockHeader(ctx context.Context, rpcClient *rpc.Client, block string) (*blockHeader, error) {
+	var raw json.RawMessage
+	if err := rpcClient.CallContext(ctx, &raw, "eth_getBlockByNumber", block, false); err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, errBlockNotFound
+	}
+
+	var header blockHeader
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block header: %w", err)
+	}
+
+	return &header, nil
+}
+
+// dialNode connects to a node, sending its configured headers on every call.
+// Nodes without TLS settings use Options.ClientTLS, if set. HTTP requests
+// are subject to Options.RateLimit and retried once if the provider answers
+// with 429, rateLimited is set if the retry is rate limited as well. With
+// Options.Gzip, responses are requested gzip-compressed.
+func (c *Checker) dialNode(ctx context.Context, n config.NodeInfo, rateLimited *atomic.Bool) (*rpc.Client, error) {
+	options := make([]rpc.ClientOption, 0, len(n.Headers)+1)
+	for key, value := range n.Headers {
+		options = append(options, rpc.WithHeader(key, value))
+	}
+
+	tlsConfig := n.TLS
+	if tlsConfig == nil && strings.HasPrefix(n.Address, "https://") {
+		tlsConfig = c.opts.ClientTLS
+	}
+
+	var httpClient *http.Client
+	if tlsConfig != nil {
+		var err error
+		httpClient, err = c.httpClient(*tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.limiter != nil {
+		transport := http.DefaultTransport
+		if httpClient != nil {
+			transport = httpClient.Transport
+		}
+		httpClient = &http.Client{
+			Transport: &rateLimitedTransport{
+				base:    transport,
+				limiter: c.limiter,
+				logger:  c.log(ctx),
+			},
+		}
+	}
+	if strings.HasPrefix(n.Address, "http://") || strings.HasPrefix(n.Address, "https://") {
+		transport := http.DefaultTransport
+		if httpClient != nil {
+			transport = httpClient.Transport
+		}
+		if c.opts.Gzip {
+			transport = &gzipTransport{base: transport}
+		}
+		httpClient = &http.Client{
+			Transport: &throttleTransport{
+				base:        transport,
+				logger:      c.log(ctx),
+				rateLimited: rateLimited,
+			},
+		}
+	}
+	if httpClient != nil {
+		options = append(options, rpc.WithHTTPClient(httpClient))
+	}
+
+	return rpc.DialOptions(ctx, n.Address, options...)
+}
+
+// Sources of a node's head block number, see Options.HeadSource
+const (
+	HeadSourceBlockNumber = "block-number" // eth_blockNumber
+	HeadSourceLatestBlock = "latest-block" // number of eth_getBlockByNumber("latest")
+)
+
+// getHead returns the head block number of a node from Options.HeadSource.
+// Some providers' eth_blockNumber lags the latest block they serve, so
+// reading the latest block keeps the head and the compared block hashes on
+// the same source.
+func (c *Checker) getHead(ctx context.Context, rpcClient *rpc.Client) (uint64, error) {
+	if c.opts.HeadSource == HeadSourceLatestBlock {
+		header, err := getBlockHeader(ctx, rpcClient, "latest")
+		if err != nil {
+			return 0, err
+		}
+		return uint64(header.Number), nil
+	}
+
+	return c.getBlockNumber(ctx, rpcClient)
+}
+
+// getTrustedBlock returns the finalized block reported by a trusted peer
+func getTrustedBlock(ctx context.Context, address string) (*BlockRef, error) {
+	rpcClient, err := rpc.DialContext(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer rpcClient.Close()
+
+	header, err := getBlockHeader(ctx, rpcClient, "finalized")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finalized block: %w", err)
+	}
+
+	return &BlockRef{
+		Number: uint64(header.Number),
+		Hash:   header.Hash,
+	}, nil
+}
+
+func (c *Checker) checkNode(ctx context.Context, n config.NodeInfo, trustedBlock *BlockRef) (info NodeResult) {
+	// Every message logged for the node is tagged with the node, also by
+	// the helpers called with ctx
+	logger := c.nodeLogger(n)
+	ctx = withLogger(ctx, logger)
+
+	info = NodeResult{
+		ID:           n.ID,
+		Chain:        n.Chain,
+		Address:      n.Address,
+		BlockHashes:  make(map[uint64]common.Hash),
+		BlockHeaders: make(map[uint64]HeaderInfo),
+		Trusted:      n.Trusted,
+		Priority:     n.Priority,
+		Timings: Timings{
+			Blocks: make(map[uint64]time.Duration),
+		},
+	}
+
+	checkStart := time.Now()
+	conn := c.clients.acquire(n.Address)
+	defer func() {
+		info.Timings.Total = time.Since(checkStart)
+		info.RateLimited = conn.rateLimited.Load()
+
+		// RPC errors may contain the node URL including its secrets
+		info.Error = redactError(info.Error, n.Address)
+		info.ArchiveError = config.RedactSecrets(info.ArchiveError, n.Address)
+		info.BlockError = config.RedactSecrets(info.BlockError, n.Address)
+		if info.Logs != nil {
+			info.Logs.Error = config.RedactSecrets(info.Logs.Error, n.Address)
+		}
+		if info.SmokeCall != nil {
+			info.SmokeCall.Error = config.RedactSecrets(info.SmokeCall.Error, n.Address)
+		}
+		if info.CustomCheck != nil {
+			info.CustomCheck.Error = config.RedactSecrets(info.CustomCheck.Error, n.Address)
+		}
+	}()
+
+	// Connect and get chain ID. The client is kept for the next run unless
+	// the check fails.
+	defer func() {
+		c.clients.release(n.Address, conn, info.Error == nil)
+	}()
+	chainID, err := c.connectNode(ctx, n, &info, conn)
+	if err != nil {
+		info.Error = err
+		return info
+	}
+	rpcClient := conn.client
+
+	ethClient := ethclient.NewClient(rpcClient)
+	info.ChainID = chainID
+
+	// Get block number
+	start := time.Now()
+	blockNumber, err := c.getHead(ctx, rpcClient)
+	info.Timings.BlockNumber = time.Since(start)
+	if err != nil {
+		info.Error = fmt.Errorf("failed to get block number: %w", err)
+		return info
+	}
+	info.BlockNumber = blockNumber
+	liveness := livenessProbe{head: blockNumber, time: time.Now()}
+
+	// Get genesis block hash
+	if c.opts.CheckGenesis {
+		c.getGenesisHash(ctx, rpcClient, &info)
+	}
+
+	// Remember the hash below the head to compare it after the reorg delay
+	var reorg *reorgProbe
+	if c.opts.CheckReorg {
+		reorg = c.startReorgCheck(ctx, rpcClient, blockNumber)
+	}
+
+	// Get sync status
+	start = time.Now()
+	syncProgress, err := ethClient.SyncProgress(ctx)
+	info.Timings.SyncStatus = time.Since(start)
+	if err != nil {
+		logger.Warn("failed to get sync status",
+			"error", err)
+	} else if syncProgress != nil {
+		info.Syncing = true
+		info.SyncStatus = &SyncStatus{
+			StartingBlock: syncProgress.StartingBlock,
+			CurrentBlock:  syncProgress.CurrentBlock,
+			HighestBlock:  syncProgress.HighestBlock,
+		}
+	}
+
+	// Get peer count (not all clients expose net_peerCount)
+	start = time.Now()
+	peerCount, err := ethClient.PeerCount(ctx)
+	info.Timings.PeerCount = time.Since(start)
+	if err != nil {
+		logger.Debug("failed to get peer count",
+			"error", err)
+	} else {
+		info.PeerCount = &peerCount
+	}
+
+	// Get network ID (not all clients expose net_version)
+	start = time.Now()
+	err = rpcClient.CallContext(ctx, &info.NetVersion, "net_version")
+	info.Timings.NetVersion = time.Since(start)
+	if err != nil {
+		logger.Debug("failed to get net version",
+			"error", err)
+	}
+
+	// Get client software and version
+	c.getClientVersion(ctx, rpcClient, &info)
+
+	// Get gas price
+	if c.opts.GasPriceTolerance > 0 {
+		start = time.Now()
+		gasPrice, err := ethClient.SuggestGasPrice(ctx)
+		info.Timings.GasPrice = time.Since(start)
+		if err != nil {
+			logger.Warn("failed to get gas price",
+				"error", err)
+		} else {
+			info.GasPrice = gasPrice
+		}
+	}
+
+	// Get base fee
+	if c.opts.BaseFeeTolerance > 0 {
+		c.getBaseFee(ctx, ethClient, &info)
+	}
+
+	if len(c.opts.HashTags) > 0 {
+		// Get blocks resolved for the configured tags
+		info.TagBlocks = make(map[string]BlockRef)
+		info.Timings.Tags = make(map[string]time.Duration)
+		for _, tag := range c.opts.HashTags {
+			start := time.Now()
+			header, err := getBlockHeader(ctx, rpcClient, tag)
+			info.Timings.Tags[tag] = time.Since(start)
+			if err != nil {
+				logger.Warn("failed to get tagged block",
+					"tag", tag,
+					"error", err)
+				continue
+			}
+
+			info.TagBlocks[tag] = BlockRef{
+				Number: uint64(header.Number),
+				Hash:   header.Hash,
+			}
+		}
+	} else {
+		// Get block hashes for last N blocks, or for the configured block
+		// range, using raw RPC calls
+		targetBlocks := hashBlocks(blockNumber, c.opts.HashConfirmations, c.blockHashCount(n.Chain))
+		if c.opts.HashRange != nil {
+			targetBlocks = c.opts.HashRange.blocks(blockNumber)
+		}
+
+		headers, headerErrs := c.fetchBlockHeaders(ctx, rpcClient, targetBlocks, info.Timings.Blocks)
+
+		var missing int
+		for i, targetBlock := range targetBlocks {
+			header, err := headers[i], headerErrs[i]
+			if errors.Is(err, errBlockNotFound) {
+				logger.Warn("block not found",
+					"block", targetBlock)
+				missing++
+				continue
+			}
+			if err != nil {
+				logger.Warn("failed to get block",
+					"block", targetBlock,
+					"error", err)
+				continue
+			}
+
+			info.BlockHashes[targetBlock] = header.Hash
+			info.BlockHeaders[targetBlock] = newHeaderInfo(header)
+		}
+
+		// Historical blocks are missing on nodes that pruned them
+		if c.opts.HashRange != nil && missing > 0 {
+			info.Warnings = append(info.Warnings,
+				fmt.Sprintf("%d of %d blocks in range %s not available, the node may have pruned them", missing, len(targetBlocks), c.opts.HashRange))
+		}
+	}
+
+	// Get hash of the trusted peer's finalized block
+	if trustedBlock != nil {
+		header, err := getBlockHeader(ctx, rpcClient, fmt.Sprintf("0x%x", trustedBlock.Number))
+		if err != nil {
+			logger.Warn("failed to get trusted peer finalized block",
+				"block", trustedBlock.Number,
+				"error", err)
+		} else {
+			info.TrustedBlockHash = &header.Hash
+		}
+	}
+
+	// Get hashes of the blocks pinned in the config. Nodes that pruned
+	// them can't be checked against the pins.
+	for _, pin := range n.PinnedHashes {
+		header, err := getBlockHeader(ctx, rpcClient, fmt.Sprintf("0x%x", pin.Block))
+		if err != nil {
+			logger.Debug("failed to get pinned block",
+				"block", pin.Block,
+				"error", err)
+			info.Warnings = append(info.Warnings, fmt.Sprintf("pinned block %d not available, the node may have pruned it", pin.Block))
+			continue
+		}
+		if info.PinnedHashes == nil {
+			info.PinnedHashes = make(map[uint64]common.Hash)
+		}
+		info.PinnedHashes[pin.Block] = header.Hash
+	}
+
+	// Check debug mode, unless the chain or upstream doesn't support it
+	if c.opts.CheckDebugMode && !n.DebugUnsupported {
+		if method, ok := c.expensive.debug(n.Address, c.opts.DebugCheckTTL); ok {
+			info.DebugOK = true
+			info.DebugMethod = method
+			info.CachedChecks = append(info.CachedChecks, "debug")
+		} else {
+			c.checkDebug(ctx, rpcClient, blockNumber, &info)
+			if info.DebugOK && c.opts.DebugCheckTTL > 0 {
+				c.expensive.storeDebug(n.Address, info.DebugMethod)
+			}
+		}
+	} else {
+		info.DebugOK = true // Skip check
+	}
+
+	// Check transaction pool
+	if c.opts.CheckTxPool {
+		c.checkTxPool(ctx, rpcClient, &info)
+	}
+
+	// Check eth_getLogs
+	if c.opts.CheckGetLogs && c.opts.GetLogsRange > 0 {
+		c.checkGetLogs(ctx, rpcClient, blockNumber, &info)
+	}
+
+	// Check the eth_call of the chain
+	if smokeCall := c.cfg.Chains[n.Chain].SmokeCall; c.opts.CheckSmokeCall && smokeCall != nil {
+		c.checkSmokeCall(ctx, rpcClient, *smokeCall, &info)
+	}
+
+	// Run the external check command
+	if c.opts.CustomCmd != "" {
+		c.checkCustom(ctx, n, &info)
+	}
+
+	// Check archive state
+	if c.opts.CheckArchive && c.expensive.archive(n.Address, c.opts.DebugCheckTTL) {
+		info.ArchiveOK = true
+		info.CachedChecks = append(info.CachedChecks, "archive")
+	} else if c.opts.CheckArchive {
+		start := time.Now()
+		_, err := ethClient.BalanceAt(ctx, common.Address{}, new(big.Int).SetUint64(c.opts.ArchiveBlock))
+		info.Timings.Archive = time.Since(start)
+		switch {
+		case err == nil:
+			info.ArchiveOK = true
+			if c.opts.DebugCheckTTL > 0 {
+				c.expensive.storeArchive(n.Address)
+			}
+		case isMissingStateError(err):
+			info.ArchiveError = "node does not retain historical state"
+			logger.Debug("archive check failed",
+				"block", c.opts.ArchiveBlock,
+				"error", err)
+		default:
+			info.ArchiveError = err.Error()
+			logger.Warn("archive check failed",
+				"block", c.opts.ArchiveBlock,
+				"error", err)
+		}
+	}
+
+	// Check that the latest block is internally consistent
+	if c.opts.DeepBlockCheck {
+		c.checkBlockConsistency(ctx, rpcClient, blockNumber, &info)
+	}
+
+	// Check that the remembered block hash has not changed
+	if reorg != nil {
+		c.finishReorgCheck(ctx, rpcClient, reorg, &info)
+	}
+
+	// Check that the head advances
+	if c.opts.CheckLiveness {
+		c.finishLivenessCheck(ctx, rpcClient, liveness, &info)
+	}
+
+	return info
+}
+
+// missingStateErrors are error fragments returned by clients when the state
+// of an old block has been pruned:
+//   - geth: "missing trie node", "historical state not available"
+//   - erigon: "state not available", "pruned"
+//   - nethermind: "missing trie node", "no state available"
+var missingStateErrors = []string{
+	"missing trie node",
+	"historical state",
+	"state not available",
+	"no state available",
+	"pruned",
+}
+
+// isMissingStateError reports whether err means that the node does not
+// retain the requested historical state
+func isMissingStateError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range missingStateErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) checkBlockHashes(result *ChainResult) {
+	// Build map of block number -> hash -> indexes of the nodes that have
+	// this hash, and -> IDs of the baseline nodes that have it
+	blockHashNodes := make(map[uint64]map[common.Hash][]int)
+	trustedHashNodes := make(map[uint64]map[common.Hash][]string)
+	for _, node := range result.baselineNodes() {
+		for blockNum, hash := range node.BlockHashes {
+			addHashVote(trustedHashNodes, blockNum, hash, node.ID)
+		}
+	}
+
+	// Headers by block number and hash for reporting which fields differ
+	var headers map[uint64]map[common.Hash]HeaderInfo
+	if c.opts.CompareHeaders {
+		headers = make(map[uint64]map[common.Hash]HeaderInfo)
+	}
+
+	addHeader := func(node NodeResult, blockNum uint64, hash common.Hash) {
+		if header, ok := node.BlockHeaders[blockNum]; ok && headers != nil {
+			if headers[blockNum] == nil {
+				headers[blockNum] = make(map[common.Hash]HeaderInfo)
+			}
+			headers[blockNum][hash] = header
+		}
+	}
+
+	for i, node := range result.Nodes {
+		if node.Error != nil {
+			continue
+		}
+		for blockNum, hash := range node.BlockHashes {
+			addHashVote(blockHashNodes, blockNum, hash, i)
+			addHeader(node, blockNum, hash)
+		}
+	}
+
+	// The reference node doesn't vote but its headers are compared
+	if ref := result.Reference; ref != nil {
+		for blockNum, hash := range ref.BlockHashes {
+			addHeader(*ref, blockNum, hash)
+		}
+	}
+
+	// For each block, find the majority hash and report nodes with different hashes
+	for blockNum, hashMap := range blockHashNodes {
+		// Check distinct hashes at the tip block, the newest compared block
+		if c.opts.MaxTipHashes > 0 && blockNum+c.opts.HashConfirmations == result.MaxBlockNumber && len(hashMap) > c.opts.MaxTipHashes {
+			result.Errors = append(result.Errors, fmt.Sprintf("excessive tip divergence: %d distinct hashes at block %d (max allowed: %d)", len(hashMap), blockNum, c.opts.MaxTipHashes))
+			result.Passed = false
+		}
+
+		label := fmt.Sprintf("block %d", blockNum)
+		result.HashConsensus[blockNum] = c.reportHashMismatches(result, hashMap, headers[blockNum], baselineHash(result, trustedHashNodes[blockNum], label), label)
+	}
+}
+
+// addHashVote records that node, an index or ID, reported hash for block
+func addHashVote[T int | string](votes map[uint64]map[common.Hash][]T, block uint64, hash common.Hash, node T) {
+	if votes[block] == nil {
+		votes[block] = make(map[common.Hash][]T)
+	}
+	votes[block][hash] = append(votes[block][hash], node)
+}
+
+// checkGasPrices reports nodes whose gas price deviates from the chain's
+// median by more than Options.GasPriceTolerance percent. The comparison is
+// skipped with fewer than 3 gas prices since the median isn't meaningful.
+func (c *Checker) checkGasPrices(result *ChainResult) {
+	var prices []*big.Int
+	for _, node := range result.Nodes {
+		if node.Error == nil && node.GasPrice != nil {
+			prices = append(prices, node.GasPrice)
+		}
+	}
+
+	if len(prices) < 3 {
+		return
+	}
+
+	median := medianBigInt(prices)
+	if median.Sign() == 0 {
+		return
+	}
+
+	for _, node := range result.Nodes {
+		if node.Error != nil || node.GasPrice == nil {
+			continue
+		}
+
+		deviation := deviationPercent(node.GasPrice, median)
+		if deviation > c.opts.GasPriceTolerance {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonGasPrice,
+				Reason:  fmt.Sprintf("gas price deviates from median: %s vs %s (%.1f%%, max allowed: %.1f%%)", node.GasPrice, median, deviation, c.opts.GasPriceTolerance),
+			})
+			result.Passed = false
+		}
+	}
+}
+
+// deviationPercent returns |value - median| / median * 100
+func deviationPercent(value, median *big.Int) float64 {
+	diff := new(big.Int).Sub(value, median)
+	deviation, _ := new(big.Float).Quo(
+		new(big.Float).SetInt(diff.Abs(diff)),
+		new(big.Float).SetInt(median),
+	).Float64()
+
+	return deviation * 100
+}
+
+// medianBigInt returns the median of values without modifying the slice
+func medianBigInt(values []*big.Int) *big.Int {
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, func(a, b *big.Int) int {
+		return a.Cmp(b)
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[mid])
+	}
+
+	sum := new(big.Int).Add(sorted[mid-1], sorted[mid])
+	return sum.Rsh(sum, 1)
+}
+
+// checkTagHashes compares the blocks resolved for each of Options.HashTags.
+// Nodes may resolve a tag to different heights, so hashes are only compared
+// between nodes that resolved the tag to the same block number. The votes
+// are kept per tag, since tags often resolve to the same height.
+func (c *Checker) checkTagHashes(result *ChainResult) {
+	result.TagHashConsensus = make(map[string]map[uint64]HashConsensusInfo, len(c.opts.HashTags))
+	for _, tag := range c.opts.HashTags {
+		// Build map of block number -> hash -> indexes of the nodes that
+		// have this hash, and -> IDs of the baseline nodes that have it
+		blockHashNodes := make(map[uint64]map[common.Hash][]int)
+		trustedHashNodes := make(map[uint64]map[common.Hash][]string)
+		for _, node := range result.baselineNodes() {
+			if block, ok := node.TagBlocks[tag]; ok {
+				addHashVote(trustedHashNodes, block.Number, block.Hash, node.ID)
+			}
+		}
+
+		for i, node := range result.Nodes {
+			if node.Error != nil {
+				continue
+			}
+			block, ok := node.TagBlocks[tag]
+			if !ok {
+				continue
+			}
+			addHashVote(blockHashNodes, block.Number, block.Hash, i)
+		}
+
+		consensus := make(map[uint64]HashConsensusInfo, len(blockHashNodes))
+		for blockNum, hashMap := range blockHashNodes {
+			label := fmt.Sprintf("%s block %d", tag, blockNum)
+			consensus[blockNum] = c.reportHashMismatches(result, hashMap, nil, baselineHash(result, trustedHashNodes[blockNum], label), label)
+		}
+		result.TagHashConsensus[tag] = consensus
+	}
+}
+
+// reportHashMismatches finds the majority hash of a block and reports nodes
+// with different hashes. hashMap holds the indexes of the nodes in
+// result.Nodes by hash, so that connectors of an upstream, which share
+// their ID, are told apart. The block is described by label in failure
+// reasons.
+// If several hashes share the highest vote count there is no majority and
+// all nodes at the block are reported. A baseline hash of the trusted nodes
+// is used as the expected hash instead of the majority. If headers has both
+// blocks, the reason names the header fields that differ.
+func (c *Checker) reportHashMismatches(result *ChainResult, hashMap map[common.Hash][]int, headers map[common.Hash]HeaderInfo, baseline *common.Hash, label string) HashConsensusInfo {
+	// Iterate hashes in a fixed order so results are stable between runs
+	hashes := slices.SortedFunc(maps.Keys(hashMap), func(a, b common.Hash) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	// Find majority hash
+	var consensus HashConsensusInfo
+	tie := false
+	for _, hash := range hashes {
+		votes := len(hashMap[hash])
+		switch {
+		case votes > consensus.MajorityVotes:
+			consensus.MajorityVotes = votes
+			consensus.MajorityHash = &hash
+			tie = false
+		case votes == consensus.MajorityVotes:
+			tie = true
+		}
+	}
+
+	if baseline != nil {
+		consensus.MajorityHash = baseline
+		consensus.MajorityVotes = len(hashMap[*baseline])
+	} else if tie {
+		consensus.MajorityHash = nil
+		consensus.MajorityVotes = 0
+	}
+
+	// All nodes agree, also with the baseline if there is one. The baseline
+	// of a reference node doesn't vote, so the nodes may agree on another
+	// hash.
+	if len(hashMap) == 1 && consensus.MajorityVotes > 0 {
+		return consensus
+	}
+	consensus.Dissenting = make(map[common.Hash][]string)
+
+	// Report nodes with different hashes
+	for _, hash := range hashes {
+		if consensus.MajorityHash != nil && hash == *consensus.MajorityHash {
+			continue
+		}
+
+		nodeIDs := make([]string, 0, len(hashMap[hash]))
+		for _, i := range hashMap[hash] {
+			nodeIDs = append(nodeIDs, result.Nodes[i].ID)
+		}
+		consensus.Dissenting[hash] = nodeIDs
+
+		reason := fmt.Sprintf("block hash mismatch at %s: got %s, no majority hash", label, hash.Hex())
+		if consensus.MajorityHash != nil {
+			reason = fmt.Sprintf("block hash mismatch at %s: got %s, expected %s", label, hash.Hex(), consensus.MajorityHash.Hex())
+
+			got, gotOK := headers[hash]
+			expected, expectedOK := headers[*consensus.MajorityHash]
+			if gotOK && expectedOK {
+				reason += fmt.Sprintf(" (%s)", describeHeaderDiff(got, expected))
+			}
+		}
+
+		for _, i := range hashMap[hash] {
+			node := result.Nodes[i]
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonHashMismatch,
+				Reason:  reason,
+			})
+			result.Passed = false
+		}
+	}
+
+	return consensus
+}
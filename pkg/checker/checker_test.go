@@ -0,0 +1,759 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+func TestCheckChainNodeMaxBlockGapOverride(t *testing.T) {
+	a := newMockNode(t, 1, 1000)
+	b := newMockNode(t, 1, 1000)
+	archive := newMockNode(t, 1, 980)
+	lagging := newMockNode(t, 1, 980)
+
+	opts := testOptions()
+	opts.MaxBlockGap = 10
+	c := newTestChecker(t, nil, opts)
+
+	gap := uint64(30)
+	archiveInfo := archive.info("archive")
+	archiveInfo.MaxBlockGap = &gap
+
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		a.info("a"), b.info("b"), archiveInfo, lagging.info("lagging"),
+	})
+
+	for _, id := range []string{"a", "b"} {
+		if codes := failedCodes(result, id); len(codes) > 0 {
+			t.Errorf("node %s failed with %v, want pass", id, codes)
+		}
+	}
+	if codes := failedCodes(result, "archive"); len(codes) > 0 {
+		t.Errorf("archive node failed with %v, want pass due to its max-block-gap override", codes)
+	}
+	if codes := failedCodes(result, "lagging"); !slices.Contains(codes, ReasonBlockGap) {
+		t.Errorf("lagging node failed with %v, want %s", codes, ReasonBlockGap)
+	}
+	if result.Nodes[2].BlockGap != 20 {
+		t.Errorf("archive block gap = %d, want 20", result.Nodes[2].BlockGap)
+	}
+}
+
+func TestCheckConcurrency(t *testing.T) {
+	const concurrency = 3
+
+	gauge := &inFlight{}
+	cfg := &config.Config{}
+	for _, chain := range []string{"ethereum", "polygon"} {
+		for i := range 5 {
+			node := newMockNode(t, 1, 1000)
+			node.delay = 5 * time.Millisecond
+			node.gauge = gauge
+
+			cfg.UpstreamConfig.Upstreams = append(cfg.UpstreamConfig.Upstreams, config.Upstream{
+				ID:         fmt.Sprintf("%s-%d", chain, i),
+				Chain:      chain,
+				Connectors: []config.Connector{{Type: "json-rpc", URL: node.server.URL}},
+			})
+		}
+	}
+
+	opts := testOptions()
+	opts.Concurrency = concurrency
+	opts.ChainParallelism = 2
+	c := newTestChecker(t, cfg, opts)
+
+	result, err := c.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !result.Passed {
+		t.Errorf("check failed: %+v", result.FailedNodes)
+	}
+
+	if peak := gauge.peak.Load(); peak > concurrency || peak == 0 {
+		t.Errorf("peak of %d requests in flight, want 1 to %d", peak, concurrency)
+	}
+}
+
+func TestCheckNodeHeaders(t *testing.T) {
+	node := newMockNode(t, 1, 1000)
+	c := newTestChecker(t, nil, testOptions())
+
+	info := node.info("a")
+	info.Headers = map[string]string{
+		"Authorization": "Bearer token",
+		"X-Api-Key":     "key",
+	}
+
+	result := c.CheckNode(context.Background(), info)
+	if result.Error != nil {
+		t.Fatalf("CheckNode: %v", result.Error)
+	}
+
+	headers := node.lastHeaders()
+	for key, value := range info.Headers {
+		if got := headers.Get(key); got != value {
+			t.Errorf("header %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestDetermineExpectedChainID(t *testing.T) {
+	node := func(chainID int64) NodeResult {
+		return NodeResult{ChainID: big.NewInt(chainID)}
+	}
+	failed := NodeResult{ChainID: big.NewInt(56), Error: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		nodes    []NodeResult
+		expected *big.Int
+		majority bool
+	}{
+		{name: "no nodes", nodes: nil, expected: nil, majority: true},
+		{name: "all failed", nodes: []NodeResult{failed}, expected: nil, majority: true},
+		{name: "unanimous", nodes: []NodeResult{node(1), node(1), node(1)}, expected: big.NewInt(1), majority: true},
+		{name: "majority", nodes: []NodeResult{node(56), node(1), node(1)}, expected: big.NewInt(1), majority: true},
+		{name: "failed nodes don't vote", nodes: []NodeResult{failed, failed, node(1)}, expected: big.NewInt(1), majority: true},
+		{name: "tie picks first responding node", nodes: []NodeResult{failed, node(56), node(1), node(1), node(56)}, expected: big.NewInt(56), majority: false},
+		{name: "three-way tie", nodes: []NodeResult{node(5), node(1), node(56)}, expected: big.NewInt(5), majority: false},
+		{name: "tie below majority doesn't count", nodes: []NodeResult{node(5), node(56), node(1), node(1)}, expected: big.NewInt(1), majority: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, majority := determineExpectedChainID(tt.nodes)
+			if majority != tt.majority {
+				t.Errorf("majority = %t, want %t", majority, tt.majority)
+			}
+			if (expected == nil) != (tt.expected == nil) || (expected != nil && expected.Cmp(tt.expected) != 0) {
+				t.Errorf("expected chain ID = %v, want %v", expected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckChainChainIDSplit(t *testing.T) {
+	a := newMockNode(t, 1, 1000)
+	b := newMockNode(t, 1, 1000)
+	c := newMockNode(t, 56, 1000)
+	d := newMockNode(t, 56, 1000)
+
+	checker := newTestChecker(t, nil, testOptions())
+	result := checker.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		a.info("a"), b.info("b"), c.info("c"), d.info("d"),
+	})
+
+	if result.Passed {
+		t.Error("chain passed, want chain ID split")
+	}
+	if !slices.ContainsFunc(result.Errors, func(e string) bool { return strings.HasPrefix(e, "chain ID split") }) {
+		t.Errorf("errors = %v, want chain ID split", result.Errors)
+	}
+	if result.ExpectedChainID.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("expected chain ID = %v, want 1 of the first responding node", result.ExpectedChainID)
+	}
+	for _, id := range []string{"c", "d"} {
+		if codes := failedCodes(result, id); !slices.Contains(codes, ReasonChainIDMismatch) {
+			t.Errorf("node %s failed with %v, want %s", id, codes, ReasonChainIDMismatch)
+		}
+	}
+}
+
+func TestCheckBlockHashesTie(t *testing.T) {
+	c := newTestChecker(t, nil, testOptions())
+
+	hashA := testHash(1, 100, false)
+	hashB := testHash(1, 100, true)
+	newResult := func() ChainResult {
+		result := ChainResult{
+			Chain:          testChain,
+			MaxBlockNumber: 100,
+			Passed:         true,
+			HashConsensus:  make(map[uint64]HashConsensusInfo),
+		}
+		for i, id := range []string{"a", "b", "c", "d"} {
+			hash := hashA
+			if i%2 == 1 {
+				hash = hashB
+			}
+			result.Nodes = append(result.Nodes, NodeResult{
+				ID:          id,
+				Chain:       testChain,
+				Address:     "https://" + id + ".example.com",
+				BlockHashes: map[uint64]common.Hash{100: hash},
+			})
+		}
+		return result
+	}
+
+	var first []FailedNode
+	for run := range 20 {
+		result := newResult()
+		c.checkBlockHashes(&result)
+
+		if result.Passed {
+			t.Fatal("chain passed with a 2-2 hash split")
+		}
+
+		consensus := result.HashConsensus[100]
+		if consensus.MajorityHash != nil || consensus.MajorityVotes != 0 {
+			t.Errorf("majority = %v with %d votes, want none", consensus.MajorityHash, consensus.MajorityVotes)
+		}
+		if len(consensus.Dissenting) != 2 {
+			t.Errorf("got %d dissenting hashes, want 2", len(consensus.Dissenting))
+		}
+
+		// All nodes are flagged since there is no agreement
+		if len(result.FailedNodes) != 4 {
+			t.Fatalf("got %d failed nodes, want 4: %+v", len(result.FailedNodes), result.FailedNodes)
+		}
+		for _, fn := range result.FailedNodes {
+			if fn.Code != ReasonHashMismatch || !strings.HasSuffix(fn.Reason, "no majority hash") {
+				t.Errorf("unexpected failure of node %s: %s %s", fn.ID, fn.Code, fn.Reason)
+			}
+		}
+
+		// Failures are reported in hash order, the same in every run
+		if run == 0 {
+			first = result.FailedNodes
+			continue
+		}
+		if !slices.EqualFunc(first, result.FailedNodes, func(a, b FailedNode) bool {
+			return a.ID == b.ID && a.Reason == b.Reason
+		}) {
+			t.Fatalf("run %d reported %+v, first run %+v", run, result.FailedNodes, first)
+		}
+	}
+}
+
+func TestCheckTagHashesSameHeight(t *testing.T) {
+	opts := testOptions()
+	opts.HashTags = []string{"finalized", "safe"}
+	c := newTestChecker(t, nil, opts)
+
+	// All nodes resolve both tags to block 100, node c has another safe hash
+	result := ChainResult{Chain: testChain, MaxBlockNumber: 100, Passed: true}
+	for _, id := range []string{"a", "b", "c"} {
+		safe := testHash(1, 100, id == "c")
+		result.Nodes = append(result.Nodes, NodeResult{
+			ID:      id,
+			Chain:   testChain,
+			Address: "https://" + id + ".example.com",
+			TagBlocks: map[string]BlockRef{
+				"finalized": {Number: 100, Hash: testHash(1, 100, false)},
+				"safe":      {Number: 100, Hash: safe},
+			},
+		})
+	}
+
+	c.checkTagHashes(&result)
+
+	finalized := result.TagHashConsensus["finalized"][100]
+	if finalized.MajorityVotes != 3 || len(finalized.Dissenting) != 0 {
+		t.Errorf("finalized consensus = %d votes, dissenting %v, want 3 votes and none dissenting", finalized.MajorityVotes, finalized.Dissenting)
+	}
+	safe := result.TagHashConsensus["safe"][100]
+	if safe.MajorityVotes != 2 || !slices.Equal(safe.Dissenting[testHash(1, 100, true)], []string{"c"}) {
+		t.Errorf("safe consensus = %d votes, dissenting %v, want 2 votes and c dissenting", safe.MajorityVotes, safe.Dissenting)
+	}
+
+	if result.Passed {
+		t.Error("chain passed with a safe hash mismatch")
+	}
+	if codes := failedCodes(result, "c"); !slices.Equal(codes, []ReasonCode{ReasonHashMismatch}) {
+		t.Errorf("node c failed with %v, want %s", codes, ReasonHashMismatch)
+	}
+}
+
+func TestMaxBlockGapPrecedence(t *testing.T) {
+	configGap := uint64(50)
+	configCount := 2
+	cfg := &config.Config{
+		Chains: map[string]config.ChainConfig{
+			"polygon":  {MaxBlockGap: &configGap, BlockHashCount: &configCount},
+			"arbitrum": {MaxBlockGap: &configGap},
+		},
+	}
+
+	opts := testOptions()
+	opts.MaxBlockGap = 10
+	opts.BlockHashCount = 5
+	opts.ChainMaxBlockGap = map[string]uint64{"arbitrum": 200, "base": 100}
+	c := newTestChecker(t, cfg, opts)
+
+	tests := []struct {
+		chain string
+		gap   uint64
+		count int
+	}{
+		{chain: "ethereum", gap: 10, count: 5},  // global default
+		{chain: "polygon", gap: 50, count: 2},   // chain config
+		{chain: "base", gap: 100, count: 5},     // --chain-gap
+		{chain: "arbitrum", gap: 200, count: 5}, // --chain-gap over chain config
+	}
+
+	for _, tt := range tests {
+		if gap := c.maxBlockGap(tt.chain); gap != tt.gap {
+			t.Errorf("maxBlockGap(%s) = %d, want %d", tt.chain, gap, tt.gap)
+		}
+		if count := c.blockHashCount(tt.chain); count != tt.count {
+			t.Errorf("blockHashCount(%s) = %d, want %d", tt.chain, count, tt.count)
+		}
+	}
+}
+
+func TestCheckChainMaxBlockGapOverrides(t *testing.T) {
+	configGap := uint64(30)
+	nodeGap := uint64(5)
+	cfg := &config.Config{
+		Chains: map[string]config.ChainConfig{testChain: {MaxBlockGap: &configGap}},
+	}
+
+	head := newMockNode(t, 1, 1000)
+	behind := newMockNode(t, 1, 980)
+	strict := newMockNode(t, 1, 990)
+
+	opts := testOptions()
+	opts.MaxBlockGap = 10
+	c := newTestChecker(t, cfg, opts)
+
+	strictInfo := strict.info("strict")
+	strictInfo.MaxBlockGap = &nodeGap
+
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		head.info("head"), behind.info("behind"), strictInfo,
+	})
+
+	// The chain config allows a gap of 30 over the global 10
+	if codes := failedCodes(result, "behind"); len(codes) > 0 {
+		t.Errorf("behind failed with %v, want pass within the chain gap", codes)
+	}
+	// The node override takes precedence over the chain config
+	if codes := failedCodes(result, "strict"); !slices.Contains(codes, ReasonBlockGap) {
+		t.Errorf("strict failed with %v, want %s", codes, ReasonBlockGap)
+	}
+}
+
+// collidingResult returns a result of two connectors of an upstream whose
+// addresses only differ in their API key, the second of which failed
+func collidingResult() *CheckResult {
+	a := "https://eth.example.com/?apikey=first"
+	b := "https://eth.example.com/?apikey=second"
+	failed := FailedNode{ID: "infura", Chain: testChain, Address: b, Code: ReasonBlockGap, Reason: "block gap too large"}
+
+	return &CheckResult{
+		ChainResults: []ChainResult{{
+			Chain: testChain,
+			Nodes: []NodeResult{
+				{ID: "infura", Chain: testChain, Address: a},
+				{ID: "infura", Chain: testChain, Address: b},
+			},
+			FailedNodes: []FailedNode{failed},
+		}},
+		FailedNodes: []FailedNode{failed},
+	}
+}
+
+func TestSummaryRedactedAddresses(t *testing.T) {
+	summary := collidingResult().Summary()
+	if summary.PassedNodes != 1 || summary.FailedNodes != 1 {
+		t.Errorf("passed %d and failed %d nodes, want 1 and 1", summary.PassedNodes, summary.FailedNodes)
+	}
+}
+
+func TestMarshalRedactsAddress(t *testing.T) {
+	result := collidingResult()
+	result.ChainResults[0].Nodes[0].Timings.Total = time.Second
+	result.ChainResults[0].Nodes[1].Timings.Total = time.Second
+
+	data, err := json.Marshal(struct {
+		*CheckResult
+		Slowest []NodeLatency `json:"slowest"`
+	}{result, result.SlowestNodes(2)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	for _, secret := range []string{"first", "second"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("JSON contains the API key %q: %s", secret, data)
+		}
+	}
+	if got := strings.Count(string(data), "https://eth.example.com/?apikey=xxxxx"); got != 6 {
+		t.Errorf("got %d redacted addresses, want 6: %s", got, data)
+	}
+
+	// The result itself keeps the addresses that identify the nodes
+	if result.ChainResults[0].Nodes[0].Address == result.ChainResults[0].Nodes[1].Address {
+		t.Error("marshaling redacted the addresses of the result")
+	}
+}
+
+func TestHashBlocks(t *testing.T) {
+	tests := []struct {
+		name          string
+		head          uint64
+		confirmations uint64
+		count         int
+		want          []uint64
+	}{
+		{name: "from head", head: 100, count: 3, want: []uint64{100, 99, 98}},
+		{name: "confirmations deep", head: 100, confirmations: 10, count: 3, want: []uint64{90, 89, 88}},
+		{name: "no blocks", head: 100, confirmations: 10, count: 0, want: nil},
+		{name: "near genesis", head: 12, confirmations: 10, count: 5, want: []uint64{2, 1, 0}},
+		{name: "at genesis", head: 10, confirmations: 10, count: 3, want: []uint64{0}},
+		{name: "below genesis", head: 9, confirmations: 10, count: 3, want: nil},
+		{name: "genesis head", head: 0, count: 3, want: []uint64{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hashBlocks(tt.head, tt.confirmations, tt.count); !slices.Equal(got, tt.want) {
+				t.Errorf("hashBlocks(%d, %d, %d) = %v, want %v", tt.head, tt.confirmations, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNodeHashConfirmations(t *testing.T) {
+	node := newMockNode(t, 1, 1000)
+
+	opts := testOptions()
+	opts.HashConfirmations = 64
+	opts.BlockHashCount = 3
+	c := newTestChecker(t, nil, opts)
+
+	result := c.CheckNode(context.Background(), node.info("a"))
+	if result.Error != nil {
+		t.Fatalf("CheckNode: %v", result.Error)
+	}
+
+	for _, block := range []uint64{936, 935, 934} {
+		if hash, ok := result.BlockHashes[block]; !ok || hash != testHash(1, block, false) {
+			t.Errorf("hash of block %d = %s, want %s", block, hash, testHash(1, block, false))
+		}
+	}
+	if len(result.BlockHashes) != 3 {
+		t.Errorf("got hashes of blocks %v, want 936 to 934", slices.Sorted(maps.Keys(result.BlockHashes)))
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *Options)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "block hash count 1", modify: func(o *Options) { o.BlockHashCount = 1 }},
+		{name: "block hash count 0", modify: func(o *Options) { o.BlockHashCount = 0 }, wantErr: "block hash count must be at least 1, got 0"},
+		{name: "negative block hash count", modify: func(o *Options) { o.BlockHashCount = -1 }, wantErr: "block hash count must be at least 1, got -1"},
+		{name: "block hash count 0 with hash tags", modify: func(o *Options) {
+			o.BlockHashCount = 0
+			o.HashTags = []string{"finalized"}
+		}},
+		{name: "block hash count 0 with block range", modify: func(o *Options) {
+			o.BlockHashCount = 0
+			o.HashRange = &BlockRange{From: 100, To: 100}
+		}},
+		{name: "block range at max length", modify: func(o *Options) { o.HashRange = &BlockRange{From: 1, To: maxHashRange} }},
+		{name: "block range above max length", modify: func(o *Options) { o.HashRange = &BlockRange{From: 0, To: maxHashRange} }, wantErr: "spans"},
+		{name: "reversed block range", modify: func(o *Options) { o.HashRange = &BlockRange{From: 101, To: 100} }, wantErr: "from block is above to block"},
+		{name: "block range with hash tags", modify: func(o *Options) {
+			o.HashRange = &BlockRange{From: 100, To: 100}
+			o.HashTags = []string{"finalized"}
+		}, wantErr: "block range and hash tags can't be combined"},
+		{name: "min healthy ratio 0", modify: func(o *Options) { o.MinHealthyRatio = 0 }},
+		{name: "min healthy ratio 1", modify: func(o *Options) { o.MinHealthyRatio = 1 }},
+		{name: "min healthy ratio above 1", modify: func(o *Options) { o.MinHealthyRatio = 1.01 }, wantErr: "min healthy ratio must be between 0 and 1"},
+		{name: "negative min healthy ratio", modify: func(o *Options) { o.MinHealthyRatio = -0.01 }, wantErr: "min healthy ratio must be between 0 and 1"},
+		{name: "max failed per chain 0", modify: func(o *Options) { o.MaxFailedPerChain = 0 }},
+		{name: "negative max failed per chain", modify: func(o *Options) { o.MaxFailedPerChain = -1 }, wantErr: "max failed nodes per chain can't be negative"},
+		{name: "zero liveness delay", modify: func(o *Options) {
+			o.CheckLiveness = true
+			o.LivenessDelay = 0
+		}, wantErr: "liveness delay must be positive"},
+		{name: "unknown head source", modify: func(o *Options) { o.HeadSource = "safe" }, wantErr: `unsupported head source "safe"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+
+			err := opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptionsLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(o *Options)
+		warnings int
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "block hash count at limit", modify: func(o *Options) { o.BlockHashCount = lintMaxBlockHashCount }},
+		{name: "block hash count above limit", modify: func(o *Options) { o.BlockHashCount = lintMaxBlockHashCount + 1 }, warnings: 1},
+		{name: "block hash count with hash tags", modify: func(o *Options) {
+			o.BlockHashCount = lintMaxBlockHashCount + 1
+			o.HashTags = []string{"finalized"}
+		}},
+		{name: "max block gap at limit", modify: func(o *Options) { o.MaxBlockGap = lintMaxBlockGap }},
+		{name: "max block gap above limit", modify: func(o *Options) { o.MaxBlockGap = lintMaxBlockGap + 1 }, warnings: 1},
+		{name: "both above limit", modify: func(o *Options) {
+			o.BlockHashCount = lintMaxBlockHashCount + 1
+			o.MaxBlockGap = lintMaxBlockGap + 1
+		}, warnings: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+
+			if warnings := opts.Lint(); len(warnings) != tt.warnings {
+				t.Errorf("Lint = %q, want %d warnings", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestCheckNodeHeadSource(t *testing.T) {
+	// eth_blockNumber lags the latest block the node serves
+	node := newMockNode(t, 1, 1000)
+	node.handle = func(method string, params []json.RawMessage) (any, bool) {
+		if method == "eth_blockNumber" {
+			return "0x3de", true // 990
+		}
+		return nil, false
+	}
+
+	tests := []struct {
+		source string
+		head   uint64
+	}{
+		{source: "", head: 990},
+		{source: HeadSourceBlockNumber, head: 990},
+		{source: HeadSourceLatestBlock, head: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			opts := testOptions()
+			opts.HeadSource = tt.source
+			opts.BlockHashCount = 1
+			c := newTestChecker(t, nil, opts)
+
+			result := c.CheckNode(context.Background(), node.info("a"))
+			if result.Error != nil {
+				t.Fatalf("CheckNode: %v", result.Error)
+			}
+			if result.BlockNumber != tt.head {
+				t.Errorf("block number = %d, want %d", result.BlockNumber, tt.head)
+			}
+
+			// The compared hashes are anchored to the same head
+			if _, ok := result.BlockHashes[tt.head]; !ok || len(result.BlockHashes) != 1 {
+				t.Errorf("got hashes of blocks %v, want %d", slices.Sorted(maps.Keys(result.BlockHashes)), tt.head)
+			}
+		})
+	}
+}
+
+func TestAcceptsChainID(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted []uint64
+		id       *big.Int
+		want     bool
+	}{
+		{name: "first", accepted: []uint64{1, 5}, id: big.NewInt(1), want: true},
+		{name: "last", accepted: []uint64{1, 5}, id: big.NewInt(5), want: true},
+		{name: "not in set", accepted: []uint64{1, 5}, id: big.NewInt(7)},
+		{name: "empty set", id: big.NewInt(1)},
+		{name: "beyond uint64", accepted: []uint64{1}, id: new(big.Int).Lsh(big.NewInt(1), 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptsChainID(tt.accepted, tt.id); got != tt.want {
+				t.Errorf("acceptsChainID(%v, %s) = %t, want %t", tt.accepted, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckChainAcceptedChainIDs(t *testing.T) {
+	// The shadow nodes serve the blocks of chain 1, so only their chain ID
+	// differs from the other nodes
+	shadow := func(chainID uint64) *mockNode {
+		m := newMockNode(t, 1, 1000)
+		m.handle = func(method string, params []json.RawMessage) (any, bool) {
+			if method == "eth_chainId" {
+				return fmt.Sprintf("0x%x", chainID), true
+			}
+			return nil, false
+		}
+		return m
+	}
+
+	a := newMockNode(t, 1, 1000)
+	b := newMockNode(t, 1, 1000)
+	member := shadow(5)
+	outsider := shadow(7)
+	strict := shadow(5)
+
+	memberInfo := member.info("member")
+	memberInfo.AcceptedChainIDs = []uint64{1, 5}
+	outsiderInfo := outsider.info("outsider")
+	outsiderInfo.AcceptedChainIDs = []uint64{1, 5}
+
+	c := newTestChecker(t, nil, testOptions())
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		a.info("a"), b.info("b"), memberInfo, outsiderInfo, strict.info("strict"),
+	})
+
+	for _, id := range []string{"a", "b", "member"} {
+		if codes := failedCodes(result, id); len(codes) > 0 {
+			t.Errorf("node %s failed with %v, want pass", id, codes)
+		}
+	}
+	if codes := failedCodes(result, "outsider"); !slices.Contains(codes, ReasonChainIDMismatch) {
+		t.Errorf("outsider failed with %v, want %s", codes, ReasonChainIDMismatch)
+	}
+	if !slices.ContainsFunc(result.FailedNodes, func(fn FailedNode) bool {
+		return fn.ID == "outsider" && fn.Reason == "chain ID mismatch: expected one of 1, 5, got 7"
+	}) {
+		t.Errorf("failed nodes = %+v, want the accepted chain IDs in the outsider reason", result.FailedNodes)
+	}
+	// Without accepted chain IDs the node is held to the expected chain ID
+	if codes := failedCodes(result, "strict"); !slices.Contains(codes, ReasonChainIDMismatch) {
+		t.Errorf("strict failed with %v, want %s", codes, ReasonChainIDMismatch)
+	}
+	if result.ExpectedChainID.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("expected chain ID = %v, want 1", result.ExpectedChainID)
+	}
+}
+
+func TestCheckStreamMaxRuntime(t *testing.T) {
+	const maxRuntime = 200 * time.Millisecond
+
+	cfg := &config.Config{}
+	gauge := &inFlight{}
+	addNode := func(id, chain string, delay time.Duration) {
+		node := newMockNode(t, 1, 1000)
+		node.delay = delay
+		node.gauge = gauge
+		cfg.UpstreamConfig.Upstreams = append(cfg.UpstreamConfig.Upstreams, config.Upstream{
+			ID:         id,
+			Chain:      chain,
+			Connectors: []config.Connector{{Type: "json-rpc", URL: node.server.URL}},
+		})
+	}
+	addNode("fast", "ethereum", 0)
+	addNode("slow", "ethereum", time.Minute)
+	addNode("slow-polygon", "polygon", time.Minute)
+
+	opts := testOptions()
+	opts.MaxRuntime = maxRuntime
+	c := newTestChecker(t, cfg, opts)
+
+	start := time.Now()
+	results := make(map[string]ChainResult)
+	for result := range c.CheckStream(context.Background()) {
+		results[result.Chain] = result
+	}
+	if elapsed := time.Since(start); elapsed > maxRuntime+2*time.Second {
+		t.Errorf("check took %s, want about %s", elapsed, maxRuntime)
+	}
+
+	// Requests to the slow nodes are cancelled rather than left running
+	deadline := time.Now().Add(2 * time.Second)
+	for gauge.active.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if active := gauge.active.Load(); active > 0 {
+		t.Errorf("%d requests still in flight after the check", active)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("got results of %d chains, want 2", len(results))
+	}
+	for chain, result := range results {
+		if result.Passed {
+			t.Errorf("chain %s passed, want failure", chain)
+		}
+	}
+
+	for _, aborted := range []struct{ chain, id string }{{"ethereum", "slow"}, {"polygon", "slow-polygon"}} {
+		if !slices.ContainsFunc(results[aborted.chain].FailedNodes, func(fn FailedNode) bool {
+			return fn.ID == aborted.id && fn.Code == ReasonCancelled && fn.Reason == "aborted: max runtime exceeded"
+		}) {
+			t.Errorf("failed nodes of %s = %+v, want %s aborted", aborted.chain, results[aborted.chain].FailedNodes, aborted.id)
+		}
+	}
+
+	// Nodes that finished in time keep their results
+	if codes := failedCodes(results["ethereum"], "fast"); slices.Contains(codes, ReasonCancelled) {
+		t.Errorf("fast node failed with %v, want its own result", codes)
+	}
+}
+
+func TestCheckChainDebugUnsupported(t *testing.T) {
+	// Neither node serves debug_* methods, only a is marked as such
+	var debugCalls atomic.Int64
+	countDebug := func(method string, params []json.RawMessage) (any, bool) {
+		if strings.HasPrefix(method, "debug_") {
+			debugCalls.Add(1)
+		}
+		return nil, false
+	}
+	a := newMockNode(t, 1, 1000)
+	a.handle = countDebug
+	b := newMockNode(t, 1, 1000)
+
+	opts := testOptions()
+	opts.CheckDebugMode = true
+	c := newTestChecker(t, nil, opts)
+
+	unsupported := a.info("a")
+	unsupported.DebugUnsupported = true
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{unsupported, b.info("b")})
+
+	if codes := failedCodes(result, "a"); len(codes) > 0 {
+		t.Errorf("debug-unsupported node a failed with %v, want pass", codes)
+	}
+	if n := debugCalls.Load(); n != 0 {
+		t.Errorf("debug-unsupported node a got %d debug calls, want 0", n)
+	}
+	if codes := failedCodes(result, "b"); !slices.Equal(codes, []ReasonCode{ReasonDebugUnavailable}) {
+		t.Errorf("node b failed with %v, want %s", codes, ReasonDebugUnavailable)
+	}
+	if result.Passed {
+		t.Error("chain passed with node b lacking debug mode")
+	}
+}
@@ -0,0 +1,48 @@
+package checker
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// clientSemverRe matches the version part of a client version string such as
+// "v1.13.5-stable" or "2.55.1"
+var clientSemverRe = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)`)
+
+// getClientVersion fetches the client software via web3_clientVersion. Nodes
+// that don't implement the method are left with an empty client version.
+func (c *Checker) getClientVersion(ctx context.Context, rpcClient *rpc.Client, info *NodeResult) {
+	start := time.Now()
+	var version string
+	err := rpcClient.CallContext(ctx, &version, "web3_clientVersion")
+	info.Timings.ClientVersion = time.Since(start)
+	if err != nil {
+		c.log(ctx).Debug("failed to get client version",
+			"error", err)
+		return
+	}
+
+	info.ClientVersion = version
+	info.ClientName, info.ClientSemver = parseClientVersion(version)
+}
+
+// parseClientVersion extracts the lowercase client name and semantic version
+// from a client version string like "Geth/v1.13.5-stable/linux-amd64/go1.21".
+// The version is empty if none of the parts look like a version.
+func parseClientVersion(version string) (name, semver string) {
+	parts := strings.Split(version, "/")
+	name = strings.ToLower(parts[0])
+
+	// Some clients include a node name before the version
+	for _, part := range parts[1:] {
+		if m := clientSemverRe.FindStringSubmatch(part); m != nil {
+			return name, m[1]
+		}
+	}
+
+	return name, ""
+}
@@ -0,0 +1,76 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// maxCustomCheckOutput limits the stderr of a custom check kept as the
+// failure reason
+const maxCustomCheckOutput = 1024
+
+// CustomCheckStatus holds the result of Options.CustomCmd for a node.
+// Error holds the command's stderr, or how it failed if stderr is empty.
+type CustomCheckStatus struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// checkCustom runs Options.CustomCmd with sh for the node. The node is
+// passed in the NODE_URL, NODE_CHAIN and NODE_ID environment variables and
+// the command is killed after Options.CustomCmdTimeout. It counts against
+// the node concurrency limit like the other checks of the node.
+func (c *Checker) checkCustom(ctx context.Context, n config.NodeInfo, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.CustomCheck = time.Since(start)
+	}()
+
+	status := &CustomCheckStatus{}
+	info.CustomCheck = status
+
+	if c.opts.CustomCmdTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.CustomCmdTimeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.opts.CustomCmd)
+	cmd.Env = append(os.Environ(),
+		"NODE_URL="+n.Address,
+		"NODE_CHAIN="+n.Chain,
+		"NODE_ID="+n.ID,
+	)
+	cmd.Stderr = &stderr
+	// Don't wait for children of the shell that keep stderr open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err == nil {
+		status.OK = true
+		return
+	}
+
+	status.Error = strings.TrimSpace(stderr.String())
+	if len(status.Error) > maxCustomCheckOutput {
+		status.Error = status.Error[:maxCustomCheckOutput] + "..."
+	}
+	if status.Error == "" {
+		status.Error = err.Error()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		status.Error = fmt.Sprintf("timed out after %s", c.opts.CustomCmdTimeout)
+	}
+
+	c.log(ctx).Debug("custom check failed",
+		"error", err)
+}
@@ -0,0 +1,55 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// checkDebug probes the tracing API with each of Options.DebugMethods in turn
+// on the block Options.DebugBlockOffset blocks below head. The first method
+// that succeeds is recorded on the node result.
+func (c *Checker) checkDebug(ctx context.Context, rpcClient *rpc.Client, blockNumber uint64, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.Debug = time.Since(start)
+	}()
+
+	// Settled blocks are lighter to trace than the contended head block
+	if blockNumber >= c.opts.DebugBlockOffset {
+		blockNumber -= c.opts.DebugBlockOffset
+	} else {
+		blockNumber = 0
+	}
+
+	blockNumberHex := fmt.Sprintf("0x%x", blockNumber)
+	for _, method := range c.opts.DebugMethods {
+		var debugResult any
+		err := rpcClient.CallContext(ctx, &debugResult, method, debugParams(method, blockNumberHex, c.opts.DebugTracer)...)
+		if err == nil {
+			info.DebugOK = true
+			info.DebugMethod = method
+			return
+		}
+
+		c.log(ctx).Debug("debug API check failed",
+			"method", method,
+			"error", err)
+	}
+}
+
+// debugParams returns the parameters of a tracing method for a block.
+// debug_* methods take a tracer config, trace_* methods only the block.
+func debugParams(method, block, tracer string) []any {
+	switch {
+	case method == "trace_replayBlockTransactions":
+		return []any{block, []string{"trace"}}
+	case strings.HasPrefix(method, "debug_") && tracer != "":
+		return []any{block, map[string]any{"tracer": tracer}}
+	default:
+		return []any{block}
+	}
+}
@@ -0,0 +1,127 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// dialBackoff is the wait before the first connection retry. It doubles with
+// every further retry.
+const dialBackoff = 500 * time.Millisecond
+
+// connectNode dials a node and fetches its chain ID, retrying up to
+// Options.DialRetries times with backoff if the connection fails. HTTP
+// clients only connect on their first request, so the chain ID request is
+// part of connecting. Errors of the RPC call itself are not retried. A
+// pooled client of conn is used first and dialed again if it fails.
+func (c *Checker) connectNode(ctx context.Context, n config.NodeInfo, info *NodeResult, conn *nodeConn) (*big.Int, error) {
+	if conn.client != nil {
+		start := time.Now()
+		chainID, err := c.getChainID(ctx, conn.client)
+		info.Timings.ChainID = time.Since(start)
+		if err == nil {
+			return chainID, nil
+		}
+
+		c.log(ctx).Debug("pooled connection failed, dialing again",
+			"error", err)
+		conn.close()
+	}
+
+	backoff := dialBackoff
+	for attempt := 0; ; attempt++ {
+		chainID, err := c.tryConnect(ctx, n, info, conn)
+		if err == nil {
+			return chainID, nil
+		}
+
+		kind, retry := connectErrorKind(err)
+		if kind == "" {
+			return nil, err
+		}
+		err = fmt.Errorf("failed to connect: %s: %w", kind, errors.Unwrap(err))
+
+		if !retry || attempt >= c.opts.DialRetries {
+			return nil, err
+		}
+
+		c.log(ctx).Debug("connection failed, retrying",
+			"attempt", attempt+1,
+			"backoff", backoff,
+			"error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// tryConnect makes a single attempt of connectNode. Errors are wrapped with
+// the step that failed.
+func (c *Checker) tryConnect(ctx context.Context, n config.NodeInfo, info *NodeResult, conn *nodeConn) (*big.Int, error) {
+	start := time.Now()
+	rpcClient, err := c.dialNode(ctx, n, &conn.rateLimited)
+	info.Timings.Dial = time.Since(start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	start = time.Now()
+	chainID, err := c.getChainID(ctx, rpcClient)
+	info.Timings.ChainID = time.Since(start)
+	if err != nil {
+		rpcClient.Close()
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	conn.client = rpcClient
+	conn.dialedAt = time.Now()
+
+	return chainID, nil
+}
+
+// connectErrorKind describes why a connection to a node failed, or returns
+// an empty string if err is not a connection error. TLS errors are not worth
+// retrying since they don't go away during deploys.
+func connectErrorKind(err error) (kind string, retry bool) {
+	var (
+		dnsErr         *net.DNSError
+		certErr        *tls.CertificateVerificationError
+		recordErr      tls.RecordHeaderError
+		unknownAuthErr x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		netErr         net.Error
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return "DNS lookup failed", true
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused", true
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset", true
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "host unreachable", true
+	case errors.As(err, &certErr), errors.As(err, &recordErr),
+		errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr),
+		strings.Contains(err.Error(), "tls: "):
+		return "TLS handshake failed", false
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "connection timed out", true
+	default:
+		return "", false
+	}
+}
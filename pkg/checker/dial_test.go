@@ -0,0 +1,146 @@
+package checker
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+func TestConnectErrorKind(t *testing.T) {
+	opError := func(err error) error {
+		return fmt.Errorf("failed to connect: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", err)})
+	}
+
+	tests := []struct {
+		name  string
+		err   error
+		kind  string
+		retry bool
+	}{
+		{name: "dns", err: &net.DNSError{Err: "no such host", Name: "node.invalid", IsNotFound: true}, kind: "DNS lookup failed", retry: true},
+		{name: "refused", err: opError(syscall.ECONNREFUSED), kind: "connection refused", retry: true},
+		{name: "reset", err: opError(syscall.ECONNRESET), kind: "connection reset", retry: true},
+		{name: "host unreachable", err: opError(syscall.EHOSTUNREACH), kind: "host unreachable", retry: true},
+		{name: "network unreachable", err: opError(syscall.ENETUNREACH), kind: "host unreachable", retry: true},
+		{name: "timeout", err: fmt.Errorf("failed to get chain ID: %w", context.DeadlineExceeded), kind: "connection timed out", retry: true},
+		{name: "unknown authority", err: fmt.Errorf("failed to get chain ID: %w", x509.UnknownAuthorityError{}), kind: "TLS handshake failed"},
+		{name: "tls alert", err: errors.New("remote error: tls: bad certificate"), kind: "TLS handshake failed"},
+		{name: "rpc error", err: errors.New("the method eth_chainId does not exist"), kind: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, retry := connectErrorKind(tt.err)
+			if kind != tt.kind || retry != tt.retry {
+				t.Errorf("connectErrorKind(%v) = %q, %t, want %q, %t", tt.err, kind, retry, tt.kind, tt.retry)
+			}
+		})
+	}
+}
+
+// closedAddress returns the URL of a local port nothing listens on
+func closedAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	return "http://" + addr
+}
+
+// hangingAddress returns the URL of a local port that accepts connections
+// but never answers
+func hangingAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	t.Cleanup(func() {
+		l.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+
+	return "http://" + l.Addr().String()
+}
+
+func TestCheckNodeConnectionFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		timeout time.Duration
+		reason  string
+	}{
+		{name: "unreachable address", address: closedAddress(t), reason: "failed to connect: connection refused"},
+		{name: "bad hostname", address: "http://evm-node-check.invalid:8545", reason: "failed to connect: DNS lookup failed"},
+		{name: "timeout", address: hangingAddress(t), timeout: 200 * time.Millisecond, reason: "failed to connect: connection timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			opts.DialRetries = 1
+			c := newTestChecker(t, nil, opts)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			result := c.CheckNode(ctx, config.NodeInfo{ID: "a", Chain: testChain, Address: tt.address})
+			elapsed := time.Since(start)
+
+			if result.Error == nil || !strings.Contains(result.Error.Error(), tt.reason) {
+				t.Fatalf("error = %v, want %q", result.Error, tt.reason)
+			}
+
+			// Failed connections are retried once after the backoff, unless
+			// the deadline passes first
+			if tt.timeout == 0 && elapsed < dialBackoff {
+				t.Errorf("failed after %s, want a retry after %s", elapsed, dialBackoff)
+			}
+			if tt.timeout > 0 && elapsed > tt.timeout+dialBackoff {
+				t.Errorf("failed after %s, want to give up at the %s deadline", elapsed, tt.timeout)
+			}
+		})
+	}
+}
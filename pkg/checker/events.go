@@ -0,0 +1,25 @@
+package checker
+
+// EventSink receives results while a check runs, e.g. to stream them to a
+// message bus instead of waiting for the CheckResult.
+//
+// Nodes and chains are checked in parallel, so the methods are called from
+// several goroutines at once and must be safe for concurrent use. They are
+// called synchronously and delay the check until they return; slow sinks
+// should hand results off to a queue.
+type EventSink interface {
+	// OnNodeChecked is called when a node check finishes, before the node
+	// is compared to the other nodes of its chain. Failures found by the
+	// comparison, such as block gaps or hash mismatches, are only part of
+	// the ChainResult.
+	OnNodeChecked(NodeResult)
+
+	// OnChainComplete is called with the validated result of a chain
+	OnChainComplete(ChainResult)
+}
+
+// NopEventSink is an EventSink that ignores all events
+type NopEventSink struct{}
+
+func (NopEventSink) OnNodeChecked(NodeResult)    {}
+func (NopEventSink) OnChainComplete(ChainResult) {}
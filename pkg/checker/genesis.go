@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// getGenesisHash records the hash of block 0. Nodes that fail to return it
+// are not compared.
+func (c *Checker) getGenesisHash(ctx context.Context, rpcClient *rpc.Client, info *NodeResult) {
+	start := time.Now()
+	header, err := getBlockHeader(ctx, rpcClient, "0x0")
+	info.Timings.Genesis = time.Since(start)
+	if err != nil {
+		c.log(ctx).Warn("failed to get genesis block",
+			"error", err)
+		return
+	}
+
+	info.GenesisHash = &header.Hash
+}
+
+// checkGenesis fails nodes whose genesis hash differs from the genesis hash
+// of the reference or trusted nodes, or of the majority. A node with another genesis block
+// is on another network whatever chain ID it reports. If several genesis
+// hashes tie for the most votes the chain fails instead.
+func (c *Checker) checkGenesis(result *ChainResult) {
+	votes := make(map[common.Hash][]string)
+	for _, node := range result.Nodes {
+		if node.Error != nil || node.GenesisHash == nil {
+			continue
+		}
+		votes[*node.GenesisHash] = append(votes[*node.GenesisHash], node.ID)
+	}
+
+	trustedVotes := make(map[common.Hash][]string)
+	for _, node := range result.baselineNodes() {
+		if node.GenesisHash != nil {
+			trustedVotes[*node.GenesisHash] = append(trustedVotes[*node.GenesisHash], node.ID)
+		}
+	}
+
+	if len(votes) == 0 || (len(votes) == 1 && len(trustedVotes) == 0) {
+		return // All nodes agree
+	}
+
+	hashes := slices.SortedFunc(maps.Keys(votes), func(a, b common.Hash) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	expected := baselineHash(result, trustedVotes, "genesis block")
+	if expected == nil {
+		tie := false
+		for _, hash := range hashes {
+			switch {
+			case expected == nil || len(votes[hash]) > len(votes[*expected]):
+				expected = &hash
+				tie = false
+			case len(votes[hash]) == len(votes[*expected]):
+				tie = true
+			}
+		}
+
+		if tie {
+			parts := make([]string, 0, len(hashes))
+			for _, hash := range hashes {
+				parts = append(parts, fmt.Sprintf("%s (%s)", hash.Hex(), strings.Join(votes[hash], ", ")))
+			}
+			result.Errors = append(result.Errors, fmt.Sprintf("genesis hash split: %s", strings.Join(parts, ", ")))
+			result.Passed = false
+			return
+		}
+	}
+
+	for _, node := range result.Nodes {
+		if node.Error != nil || node.GenesisHash == nil || *node.GenesisHash == *expected {
+			continue
+		}
+
+		result.FailedNodes = append(result.FailedNodes, FailedNode{
+			ID:      node.ID,
+			Chain:   node.Chain,
+			Address: node.Address,
+			Code:    ReasonGenesisMismatch,
+			Reason:  fmt.Sprintf("genesis hash mismatch: got %s, expected %s", node.GenesisHash.Hex(), expected.Hex()),
+		})
+		result.Passed = false
+	}
+}
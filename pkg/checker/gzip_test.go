@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// gzipWriter compresses everything written to a response
+type gzipWriter struct {
+	http.ResponseWriter
+	zw *gzip.Writer
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	return w.zw.Write(p)
+}
+
+// newGzipNode serves m with gzip-compressed responses to requests that
+// accept them. compressed counts the compressed responses.
+func newGzipNode(t *testing.T, m *mockNode, compressed *atomic.Int64) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			m.ServeHTTP(w, r)
+			return
+		}
+
+		compressed.Add(1)
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		m.ServeHTTP(&gzipWriter{ResponseWriter: w, zw: zw}, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCheckNodeGzip(t *testing.T) {
+	tests := []struct {
+		name    string
+		gzip    bool
+		headers map[string]string
+		wantErr bool
+	}{
+		{name: "default transport"},
+		{name: "gzip option", gzip: true},
+		{name: "gzip option with Accept-Encoding header", gzip: true, headers: map[string]string{"Accept-Encoding": "gzip"}},
+		// Without the option, responses to a configured Accept-Encoding
+		// header are not decompressed
+		{name: "Accept-Encoding header", headers: map[string]string{"Accept-Encoding": "gzip"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newMockNode(t, 1, 1000)
+			var compressed atomic.Int64
+			server := newGzipNode(t, node, &compressed)
+
+			opts := testOptions()
+			opts.Gzip = tt.gzip
+			c := newTestChecker(t, nil, opts)
+
+			info := node.info("a")
+			info.Address = server.URL
+			info.Headers = tt.headers
+
+			result := c.CheckNode(context.Background(), info)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("CheckNode error = %v, want error %t", result.Error, tt.wantErr)
+			}
+			if compressed.Load() == 0 {
+				t.Error("no response was compressed")
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.BlockNumber != 1000 || result.BlockHashes[1000] != testHash(1, 1000, false) {
+				t.Errorf("block %d with hash %s, want block 1000 with hash %s", result.BlockNumber, result.BlockHashes[1000], testHash(1, 1000, false))
+			}
+		})
+	}
+}
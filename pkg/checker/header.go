@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HeaderInfo holds the header fields of a compared block that explain a hash
+// mismatch
+type HeaderInfo struct {
+	Number     uint64      `json:"number"`
+	ParentHash common.Hash `json:"parent_hash"`
+	StateRoot  common.Hash `json:"state_root"`
+}
+
+func newHeaderInfo(header *blockHeader) HeaderInfo {
+	return HeaderInfo{
+		Number:     uint64(header.Number),
+		ParentHash: header.ParentHash,
+		StateRoot:  header.StateRoot,
+	}
+}
+
+// describeHeaderDiff names the fields that differ between two headers of the
+// same block. A differing parent hash means the nodes are on different forks,
+// a differing state root with the same parent means they executed the block
+// differently.
+func describeHeaderDiff(got, expected HeaderInfo) string {
+	var fields []string
+	if got.Number != expected.Number {
+		fields = append(fields, "number")
+	}
+	if got.ParentHash != expected.ParentHash {
+		fields = append(fields, "parentHash")
+	}
+	if got.StateRoot != expected.StateRoot {
+		fields = append(fields, "stateRoot")
+	}
+
+	switch len(fields) {
+	case 0:
+		return "other header fields differ"
+	case 1:
+		return fields[0] + " differs"
+	default:
+		return strings.Join(fields, ", ") + " differ"
+	}
+}
@@ -0,0 +1,32 @@
+package checker
+
+import (
+	"math/big"
+	"sync"
+)
+
+// chainIDHistory remembers the last chain ID reported by each node address
+// so that a reused Checker notices nodes whose chain ID changed between
+// checks, e.g. after a DNS record was repointed to another network
+type chainIDHistory struct {
+	mu  sync.Mutex
+	ids map[string]*big.Int
+}
+
+func newChainIDHistory() *chainIDHistory {
+	return &chainIDHistory{
+		ids: make(map[string]*big.Int),
+	}
+}
+
+// swap records id for address and returns the previously recorded chain ID,
+// nil on the first check of the node
+func (h *chainIDHistory) swap(address string, id *big.Int) *big.Int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.ids[address]
+	h.ids[address] = new(big.Int).Set(id)
+
+	return previous
+}
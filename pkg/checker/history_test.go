@@ -0,0 +1,105 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+func TestChainIDHistorySwap(t *testing.T) {
+	h := newChainIDHistory()
+
+	if previous := h.swap("https://a.example.com", big.NewInt(1)); previous != nil {
+		t.Errorf("first swap returned %v, want nil", previous)
+	}
+	if previous := h.swap("https://b.example.com/?apikey=second", big.NewInt(5)); previous != nil {
+		t.Errorf("first swap of another address returned %v, want nil", previous)
+	}
+
+	// Addresses that only differ in their secrets are different nodes
+	if previous := h.swap("https://b.example.com/?apikey=first", big.NewInt(1)); previous != nil {
+		t.Errorf("first swap of an address with another key returned %v, want nil", previous)
+	}
+
+	id := big.NewInt(5)
+	if previous := h.swap("https://a.example.com", id); previous == nil || previous.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("second swap returned %v, want 1", previous)
+	}
+
+	// The recorded chain ID is a copy
+	id.SetInt64(56)
+	if previous := h.swap("https://a.example.com", big.NewInt(5)); previous.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("third swap returned %v, want 5", previous)
+	}
+}
+
+func TestCheckChainChainIDChanged(t *testing.T) {
+	a := newMockNode(t, 1, 1000)
+	b := newMockNode(t, 1, 1000)
+	flipping := newMockNode(t, 1, 1000)
+
+	// The chain ID of the flipping node is changed between checks
+	var chainID atomic.Uint64
+	chainID.Store(1)
+	flipping.handle = func(method string, params []json.RawMessage) (any, bool) {
+		switch method {
+		case "eth_chainId":
+			return hexutil.EncodeUint64(chainID.Load()), true
+		case "net_version":
+			return big.NewInt(int64(chainID.Load())).String(), true
+		}
+		return nil, false
+	}
+
+	c := newTestChecker(t, nil, testOptions())
+	nodes := []config.NodeInfo{a.info("a"), b.info("b"), flipping.info("flipping")}
+
+	steps := []struct {
+		name    string
+		chainID uint64
+		reason  string
+	}{
+		{name: "first check", chainID: 1},
+		{name: "unchanged", chainID: 1},
+		{name: "flipped", chainID: 5, reason: "chain ID changed from 1 to 5 between cycles"},
+		{name: "stays flipped", chainID: 5},
+		{name: "flipped back", chainID: 1, reason: "chain ID changed from 5 to 1 between cycles"},
+	}
+
+	for _, step := range steps {
+		chainID.Store(step.chainID)
+		result := c.CheckChain(context.Background(), testChain, nodes)
+
+		for _, id := range []string{"a", "b"} {
+			if codes := failedCodes(result, id); len(codes) > 0 {
+				t.Errorf("%s: node %s failed with %v, want pass", step.name, id, codes)
+			}
+		}
+
+		var reasons []string
+		for _, fn := range result.FailedNodes {
+			if fn.ID == "flipping" && fn.Code == ReasonChainIDChanged {
+				reasons = append(reasons, fn.Reason)
+			}
+		}
+		switch {
+		case step.reason == "" && len(reasons) > 0:
+			t.Errorf("%s: got %q, want no chain ID change", step.name, reasons)
+		case step.reason != "" && !slices.Equal(reasons, []string{step.reason}):
+			t.Errorf("%s: got %q, want %q", step.name, reasons, step.reason)
+		}
+
+		// A node that stays on another chain is still a mismatch
+		if step.name == "stays flipped" {
+			if codes := failedCodes(result, "flipping"); !slices.Contains(codes, ReasonChainIDMismatch) {
+				t.Errorf("%s: flipping node failed with %v, want %s", step.name, codes, ReasonChainIDMismatch)
+			}
+		}
+	}
+}
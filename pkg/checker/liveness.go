@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// livenessProbe is the first observation of a node's head for the liveness
+// check
+type livenessProbe struct {
+	head uint64
+	time time.Time
+}
+
+// finishLivenessCheck waits until Options.LivenessDelay has passed since the
+// probe and reads the head again. The number of blocks the head advanced is
+// recorded on the node result.
+func (c *Checker) finishLivenessCheck(ctx context.Context, rpcClient *rpc.Client, probe livenessProbe, info *NodeResult) {
+	select {
+	case <-time.After(time.Until(probe.time.Add(c.opts.LivenessDelay))):
+	case <-ctx.Done():
+		return
+	}
+
+	start := time.Now()
+	head, err := c.getHead(ctx, rpcClient)
+	info.Timings.Liveness = time.Since(start)
+	if err != nil {
+		c.log(ctx).Warn("failed to get block number for liveness check",
+			"error", err)
+		return
+	}
+
+	var advance uint64
+	if head > probe.head {
+		advance = head - probe.head
+	}
+	info.HeadAdvance = &advance
+}
+
+// chainAdvancing reports whether any node of the chain advanced its head by
+// at least Options.MinHeadAdvance. If none did, the chain itself is not
+// producing blocks and stuck heads are not the nodes' fault.
+func (c *Checker) chainAdvancing(nodes []NodeResult) bool {
+	for _, node := range nodes {
+		if node.Error == nil && node.HeadAdvance != nil && *node.HeadAdvance >= c.opts.MinHeadAdvance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package checker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// loggerKey is the context key of the logger of a node check
+type loggerKey struct{}
+
+// nodeLogger returns the Checker's logger with the node's id, chain and
+// redacted address attached to every message
+func (c *Checker) nodeLogger(n config.NodeInfo) *slog.Logger {
+	return c.logger.With(
+		"node", n.ID,
+		"chain", n.Chain,
+		"address", config.RedactURL(n.Address))
+}
+
+// withLogger returns a copy of ctx that carries logger
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// log returns the logger carried by ctx, which is the node logger within a
+// node check, or the Checker's logger
+func (c *Checker) log(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return c.logger
+}
@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// LogsStatus holds the result of an eth_getLogs probe. Error holds the
+// provider's error message if the request was rejected.
+type LogsStatus struct {
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block"`
+	Count     int    `json:"count"`
+	Limited   bool   `json:"limited"`
+	Error     string `json:"error,omitempty"`
+}
+
+// logsLimitErrors are error fragments returned by providers that cap the
+// block range or the number of results of eth_getLogs, e.g.
+// "query returned more than 10000 results" or "block range too large"
+var logsLimitErrors = []string{
+	"more than",
+	"block range",
+	"range too large",
+	"range is too large",
+	"range is too wide",
+	"too many",
+	"exceeded",
+	"is limited to",
+}
+
+// checkGetLogs requests the logs of the last Options.GetLogsRange blocks up
+// to blockNumber without an address filter
+func (c *Checker) checkGetLogs(ctx context.Context, rpcClient *rpc.Client, blockNumber uint64, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.GetLogs = time.Since(start)
+	}()
+
+	status := &LogsStatus{
+		FromBlock: blockNumber - min(c.opts.GetLogsRange-1, blockNumber),
+		ToBlock:   blockNumber,
+	}
+	info.Logs = status
+
+	filter := map[string]string{
+		"fromBlock": fmt.Sprintf("0x%x", status.FromBlock),
+		"toBlock":   fmt.Sprintf("0x%x", status.ToBlock),
+	}
+
+	var logs []json.RawMessage
+	if err := rpcClient.CallContext(ctx, &logs, "eth_getLogs", filter); err != nil {
+		status.Error = err.Error()
+		status.Limited = isLogsLimitError(err)
+		c.log(ctx).Debug("eth_getLogs failed",
+			"from_block", status.FromBlock,
+			"to_block", status.ToBlock,
+			"error", err)
+		return
+	}
+
+	status.Count = len(logs)
+}
+
+// isLogsLimitError reports whether err means that the provider rejected the
+// eth_getLogs request because of its size
+func isLogsLimitError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range logsLimitErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package checker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+const testChain = "ethereum"
+
+// mockNode is a JSON-RPC node serving a chain of chainID with head blocks.
+// Its fields must be set before the first request.
+type mockNode struct {
+	chainID uint64
+	head    uint64
+
+	// fork changes the hashes of all blocks from this block on, if set
+	fork uint64
+
+	// delay is waited for before answering each request
+	delay time.Duration
+
+	// gauge tracks requests in flight, it may be shared between nodes
+	gauge *inFlight
+
+	// handle answers a method instead of the defaults if it returns true
+	handle func(method string, params []json.RawMessage) (result any, ok bool)
+
+	// batch rewrites the responses to batch requests, if set
+	batch func(resps []rpcResponse) []rpcResponse
+
+	server   *httptest.Server
+	requests atomic.Int64
+
+	mu      sync.Mutex
+	headers http.Header
+}
+
+// newMockNode starts a node serving chainID at head, closed at the end of
+// the test
+func newMockNode(t testing.TB, chainID, head uint64) *mockNode {
+	t.Helper()
+
+	m := &mockNode{chainID: chainID, head: head}
+	m.server = httptest.NewServer(m)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+// info returns the node config of the node
+func (m *mockNode) info(id string) config.NodeInfo {
+	return config.NodeInfo{ID: id, Chain: testChain, Address: m.server.URL}
+}
+
+// lastHeaders returns the HTTP headers of the last request
+func (m *mockNode) lastHeaders() http.Header {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.headers
+}
+
+// inFlight counts requests in flight and the peak count
+type inFlight struct {
+	active atomic.Int64
+	peak   atomic.Int64
+}
+
+func (g *inFlight) enter() {
+	n := g.active.Add(1)
+	for {
+		peak := g.peak.Load()
+		if n <= peak || g.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (g *inFlight) leave() {
+	g.active.Add(-1)
+}
+
+// rpcRequest and rpcResponse are JSON-RPC 2.0 messages
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (m *mockNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.requests.Add(1)
+	m.mu.Lock()
+	m.headers = r.Header.Clone()
+	m.mu.Unlock()
+
+	if m.gauge != nil {
+		m.gauge.enter()
+		defer m.gauge.leave()
+	}
+
+	// The server only notices a client hanging up once the body is read
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if m.delay > 0 {
+		select {
+		case <-time.After(m.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]rpcResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = m.respond(req)
+		}
+		if m.batch != nil {
+			resps = m.batch(resps)
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(m.respond(req))
+}
+
+func (m *mockNode) respond(req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+
+	if m.handle != nil {
+		if result, ok := m.handle(req.Method, req.Params); ok {
+			if err, isErr := result.(*rpcError); isErr {
+				resp.Error = err
+			} else {
+				resp.Result = result
+			}
+			return resp
+		}
+	}
+
+	switch req.Method {
+	case "eth_chainId":
+		resp.Result = hexutil.EncodeUint64(m.chainID)
+	case "eth_blockNumber":
+		resp.Result = hexutil.EncodeUint64(m.head)
+	case "eth_syncing":
+		resp.Result = false
+	case "net_peerCount":
+		resp.Result = "0x19"
+	case "net_version":
+		resp.Result = strconv.FormatUint(m.chainID, 10)
+	case "web3_clientVersion":
+		resp.Result = "Geth/v1.16.7-stable/linux-amd64/go1.25.5"
+	case "eth_getBlockByNumber":
+		var block string
+		if len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &block)
+		}
+		number, ok := m.resolve(block)
+		if !ok {
+			resp.Result = json.RawMessage("null")
+			break
+		}
+		resp.Result = m.block(number)
+	default:
+		resp.Error = &rpcError{Code: -32601, Message: "the method " + req.Method + " does not exist/is not available"}
+	}
+
+	return resp
+}
+
+// resolve returns the number of a block given as hex number or tag
+func (m *mockNode) resolve(block string) (uint64, bool) {
+	var number uint64
+	switch block {
+	case "latest", "pending":
+		number = m.head
+	case "safe":
+		number = m.head - min(m.head, 32)
+	case "finalized":
+		number = m.head - min(m.head, 64)
+	case "earliest":
+		number = 0
+	default:
+		n, err := hexutil.DecodeUint64(block)
+		if err != nil {
+			return 0, false
+		}
+		number = n
+	}
+
+	return number, number <= m.head
+}
+
+// block returns the header fields of block number
+func (m *mockNode) block(number uint64) map[string]any {
+	parent := common.Hash{}
+	if number > 0 {
+		parent = m.hash(number - 1)
+	}
+
+	return map[string]any{
+		"number":     hexutil.EncodeUint64(number),
+		"hash":       m.hash(number),
+		"parentHash": parent,
+		"stateRoot":  common.Hash{0x5},
+		"timestamp":  hexutil.EncodeUint64(1_700_000_000 + number*12),
+	}
+}
+
+// hash returns the hash of block number, which is the same on all nodes of
+// a chain unless the node forked below the block
+func (m *mockNode) hash(number uint64) common.Hash {
+	return testHash(m.chainID, number, m.fork > 0 && number >= m.fork)
+}
+
+// testHash returns a deterministic block hash
+func testHash(chainID, number uint64, forked bool) common.Hash {
+	var h common.Hash
+	binary.BigEndian.PutUint64(h[0:], chainID)
+	binary.BigEndian.PutUint64(h[8:], number)
+	if forked {
+		h[31] = 0xff
+	}
+	return h
+}
+
+// testOptions returns options for fast checks against mock nodes
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.CheckDebugMode = false
+	opts.DialRetries = 0
+	return opts
+}
+
+// newTestChecker returns a Checker for cfg that discards its logs
+func newTestChecker(t testing.TB, cfg *config.Config, opts Options) *Checker {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	c := New(cfg, opts, slog.New(slog.DiscardHandler))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// failedCodes returns the reason codes of the failures of node id
+func failedCodes(result ChainResult, id string) []ReasonCode {
+	var codes []ReasonCode
+	for _, fn := range result.FailedNodes {
+		if fn.ID == id {
+			codes = append(codes, fn.Code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,160 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// saturate takes all node check slots of c until the end of the test
+func saturate(t *testing.T, c *Checker) {
+	t.Helper()
+
+	for range cap(c.nodeSem) {
+		c.nodeSem <- struct{}{}
+	}
+	t.Cleanup(func() {
+		for range cap(c.nodeSem) {
+			<-c.nodeSem
+		}
+	})
+}
+
+func TestCancelWhileWaitingForSlot(t *testing.T) {
+	node := newMockNode(t, 1, 1000)
+	reference := newMockNode(t, 1, 1000)
+
+	opts := testOptions()
+	opts.Concurrency = 1
+	opts.References = map[string]string{testChain: reference.server.URL}
+	c := newTestChecker(t, &config.Config{Chains: map[string]config.ChainConfig{
+		testChain: {TrustedPeer: reference.server.URL},
+	}}, opts)
+	saturate(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	var nodeResult NodeResult
+	var chainResult ChainResult
+	go func() {
+		defer close(done)
+		nodeResult = c.CheckNode(ctx, node.info("a"))
+		chainResult = c.CheckChain(ctx, testChain, []config.NodeInfo{node.info("a")})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("checks blocked on a saturated node limit after the context was cancelled")
+	}
+
+	if !errors.Is(nodeResult.Error, errCheckCancelled) {
+		t.Errorf("CheckNode error = %v, want %v", nodeResult.Error, errCheckCancelled)
+	}
+	for _, prefix := range []string{"trusted peer error: check cancelled", "reference node error: check cancelled"} {
+		if !hasError(chainResult, prefix) {
+			t.Errorf("chain errors = %v, want %q", chainResult.Errors, prefix)
+		}
+	}
+	if chainResult.Passed {
+		t.Error("chain passed, want cancelled")
+	}
+	if node.requests.Load() > 0 || reference.requests.Load() > 0 {
+		t.Error("nodes were requested although no slot was free")
+	}
+}
+
+func TestFailFastDegradedChain(t *testing.T) {
+	// Chain degraded passes with a forked node, chain slow is still being
+	// checked when it's done
+	cfg := &config.Config{}
+	addNode := func(chain, id, address string) {
+		cfg.UpstreamConfig.Upstreams = append(cfg.UpstreamConfig.Upstreams, config.Upstream{
+			ID:         id,
+			Chain:      chain,
+			Connectors: []config.Connector{{Type: "json-rpc", URL: address}},
+		})
+	}
+	for _, id := range []string{"a", "b", "forked"} {
+		node := newMockNode(t, 1, 1000)
+		if id == "forked" {
+			node.fork = 990
+		}
+		addNode("degraded", id, node.server.URL)
+	}
+	slow := newMockNode(t, 1, 1000)
+	slow.delay = 5 * time.Second
+	addNode("slow", "slow", slow.server.URL)
+
+	opts := testOptions()
+	opts.FailFast = true
+	opts.MaxFailedPerChain = 1
+	c := newTestChecker(t, cfg, opts)
+
+	start := time.Now()
+	result, err := c.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("check took %s, want the slow chain cancelled", elapsed)
+	}
+
+	for _, chain := range result.ChainResults {
+		switch chain.Chain {
+		case "degraded":
+			if !chain.Passed || !chain.Degraded {
+				t.Errorf("chain degraded passed = %t, degraded = %t, want both", chain.Passed, chain.Degraded)
+			}
+		case "slow":
+			if !errors.Is(chain.Nodes[0].Error, errCheckCancelled) {
+				t.Errorf("node slow error = %v, want %v", chain.Nodes[0].Error, errCheckCancelled)
+			}
+		}
+	}
+}
+
+// BenchmarkCheckChainParallelism checks chains of slow nodes one at a time
+// and in parallel. With enough node slots, checking chains in parallel
+// takes about as long as checking one chain.
+func BenchmarkCheckChainParallelism(b *testing.B) {
+	const chains, nodesPerChain = 4, 3
+
+	cfg := &config.Config{}
+	for chain := range chains {
+		for i := range nodesPerChain {
+			node := newMockNode(b, 1, 1000)
+			node.delay = 5 * time.Millisecond
+			cfg.UpstreamConfig.Upstreams = append(cfg.UpstreamConfig.Upstreams, config.Upstream{
+				ID:         fmt.Sprintf("chain-%d-%d", chain, i),
+				Chain:      fmt.Sprintf("chain-%d", chain),
+				Connectors: []config.Connector{{Type: "json-rpc", URL: node.server.URL}},
+			})
+		}
+	}
+
+	for _, parallelism := range []int{1, chains} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			opts := testOptions()
+			opts.ChainParallelism = parallelism
+			opts.Concurrency = chains * nodesPerChain
+			c := newTestChecker(b, cfg, opts)
+
+			for b.Loop() {
+				result, err := c.Check(context.Background())
+				if err != nil {
+					b.Fatalf("Check: %v", err)
+				}
+				if !result.Passed {
+					b.Fatalf("check failed: %+v", result.FailedNodes)
+				}
+			}
+		})
+	}
+}
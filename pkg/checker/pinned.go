@@ -0,0 +1,27 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// pinnedHashMismatch describes the first pinned block the node served with
+// another hash than pinned in the config. Blocks the node didn't serve are
+// not compared.
+func pinnedHashMismatch(node NodeResult, pins []config.PinnedHash) (string, bool) {
+	for _, pin := range pins {
+		hash, ok := node.PinnedHashes[pin.Block]
+		if !ok {
+			continue
+		}
+
+		expected := common.HexToHash(pin.Hash)
+		if hash != expected {
+			return fmt.Sprintf("pinned hash mismatch at block %d: got %s, expected %s", pin.Block, hash.Hex(), expected.Hex()), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// pinnedInfo returns the node config of m with the given pins
+func pinnedInfo(m *mockNode, id string, pins ...config.PinnedHash) config.NodeInfo {
+	info := m.info(id)
+	info.PinnedHashes = pins
+	return info
+}
+
+func TestPinnedHashMismatch(t *testing.T) {
+	pin := config.PinnedHash{Block: 500, Hash: testHash(1, 500, false).Hex()}
+
+	tests := []struct {
+		name   string
+		node   NodeResult
+		reason string
+	}{
+		{name: "match", node: NodeResult{PinnedHashes: map[uint64]common.Hash{500: testHash(1, 500, false)}}},
+		{name: "not served", node: NodeResult{}},
+		{
+			name:   "mismatch",
+			node:   NodeResult{PinnedHashes: map[uint64]common.Hash{500: testHash(1, 500, true)}},
+			reason: "pinned hash mismatch at block 500: got " + testHash(1, 500, true).Hex() + ", expected " + pin.Hash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := pinnedHashMismatch(tt.node, []config.PinnedHash{pin})
+			if ok != (tt.reason != "") || reason != tt.reason {
+				t.Errorf("pinnedHashMismatch = %q, %t, want %q", reason, ok, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCheckChainPinnedHashes(t *testing.T) {
+	pin := config.PinnedHash{Block: 500, Hash: testHash(1, 500, false).Hex()}
+
+	a := newMockNode(t, 1, 1000)
+	b := newMockNode(t, 1, 1000)
+	forked := newMockNode(t, 1, 1000)
+	forked.fork = 400
+	pruned := newMockNode(t, 1, 1000)
+	pruned.handle = func(method string, params []json.RawMessage) (any, bool) {
+		var block string
+		if method == "eth_getBlockByNumber" && len(params) > 0 && json.Unmarshal(params[0], &block) == nil && block == "0x1f4" {
+			return json.RawMessage("null"), true
+		}
+		return nil, false
+	}
+
+	c := newTestChecker(t, nil, testOptions())
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		pinnedInfo(a, "a", pin), pinnedInfo(b, "b", pin), pinnedInfo(forked, "forked", pin), pinnedInfo(pruned, "pruned", pin),
+	})
+
+	for _, id := range []string{"a", "b", "pruned"} {
+		if codes := failedCodes(result, id); len(codes) > 0 {
+			t.Errorf("node %s failed with %v, want pass", id, codes)
+		}
+	}
+	if codes := failedCodes(result, "forked"); !slices.Contains(codes, ReasonPinnedHashMismatch) {
+		t.Errorf("forked node failed with %v, want %s", codes, ReasonPinnedHashMismatch)
+	}
+	if !slices.ContainsFunc(result.FailedNodes, func(fn FailedNode) bool {
+		return fn.ID == "forked" && strings.HasPrefix(fn.Reason, "pinned hash mismatch at block 500")
+	}) {
+		t.Errorf("failed nodes = %+v, want pinned hash mismatch at block 500", result.FailedNodes)
+	}
+
+	if got := result.Nodes[0].PinnedHashes[500]; got != testHash(1, 500, false) {
+		t.Errorf("pinned hash of a = %s, want %s", got, pin.Hash)
+	}
+	if !slices.ContainsFunc(result.Nodes[3].Warnings, func(w string) bool { return strings.HasPrefix(w, "pinned block 500 not available") }) {
+		t.Errorf("pruned node warnings = %v, want pinned block not available", result.Nodes[3].Warnings)
+	}
+}
+
+func TestCheckChainPinnedHashesCoordinatedFork(t *testing.T) {
+	// All nodes agree on a hash that differs from the pinned one
+	pin := config.PinnedHash{Block: 500, Hash: testHash(1, 500, false).Hex()}
+
+	var nodes []config.NodeInfo
+	for _, id := range []string{"a", "b", "c"} {
+		m := newMockNode(t, 1, 1000)
+		m.fork = 400
+		nodes = append(nodes, pinnedInfo(m, id, pin))
+	}
+
+	c := newTestChecker(t, nil, testOptions())
+	result := c.CheckChain(context.Background(), testChain, nodes)
+
+	if result.Passed {
+		t.Error("chain passed, want pinned hash mismatch")
+	}
+	for _, node := range nodes {
+		if codes := failedCodes(result, node.ID); !slices.Contains(codes, ReasonPinnedHashMismatch) {
+			t.Errorf("node %s failed with %v, want %s", node.ID, codes, ReasonPinnedHashMismatch)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// nodeConn is the client of a node. rateLimited is set by the client's HTTP
+// transport when the provider answers with 429.
+type nodeConn struct {
+	client      *rpc.Client
+	rateLimited atomic.Bool
+	dialedAt    time.Time
+}
+
+// close closes the client, if it has been dialed
+func (nc *nodeConn) close() {
+	if nc.client != nil {
+		nc.client.Close()
+		nc.client = nil
+	}
+}
+
+// clientPool keeps the dialed clients of nodes by address so that a reused
+// Checker doesn't open new HTTP connections or repeat WebSocket handshakes
+// on every run. A client is only used by one check at a time: acquire takes
+// it out of the pool and release puts it back. Clients older than ttl are
+// dialed again; with a ttl of 0 clients are closed after every check.
+type clientPool struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	conns map[string]*nodeConn
+}
+
+func newClientPool(ttl time.Duration) *clientPool {
+	return &clientPool{
+		ttl:   ttl,
+		conns: make(map[string]*nodeConn),
+	}
+}
+
+// acquire returns the pooled connection of address, or a new connection to
+// be dialed if there is none or it expired
+func (p *clientPool) acquire(address string) *nodeConn {
+	p.mu.Lock()
+	conn, ok := p.conns[address]
+	delete(p.conns, address)
+	p.mu.Unlock()
+
+	if !ok {
+		return &nodeConn{}
+	}
+	if time.Since(conn.dialedAt) >= p.ttl {
+		conn.close()
+		return &nodeConn{}
+	}
+
+	conn.rateLimited.Store(false)
+	return conn
+}
+
+// release returns conn to the pool after a check. Connections of failed
+// checks are closed so that the node is dialed again next time.
+func (p *clientPool) release(address string, conn *nodeConn, ok bool) {
+	if !ok || conn.client == nil || p.ttl <= 0 {
+		conn.close()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Concurrent checks of the same address each have their own client
+	if _, exists := p.conns[address]; exists {
+		conn.close()
+		return
+	}
+	p.conns[address] = conn
+}
+
+// close closes all pooled clients
+func (p *clientPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for address, conn := range p.conns {
+		conn.close()
+		delete(p.conns, address)
+	}
+}
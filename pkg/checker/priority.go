@@ -0,0 +1,40 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// applyPriorities marks the failures of primary nodes with their priority
+// and turns the failures of fallback nodes into warnings. A down fallback
+// is less urgent than a down primary, so it doesn't fail the chain.
+// Chain errors are not affected.
+func applyPriorities(result *ChainResult) {
+	priorities := make(map[string]string, len(result.Nodes))
+	warnings := make(map[string][]string)
+	for _, node := range result.Nodes {
+		priorities[node.Address] = node.Priority
+	}
+
+	failed := result.FailedNodes[:0]
+	for _, fn := range result.FailedNodes {
+		switch priorities[fn.Address] {
+		case config.PriorityFallback:
+			warnings[fn.Address] = append(warnings[fn.Address], fmt.Sprintf("fallback node failed: %s", fn.Reason))
+			continue
+		case config.PriorityPrimary:
+			fn.Priority = config.PriorityPrimary
+		}
+		failed = append(failed, fn)
+	}
+	result.FailedNodes = failed
+	if len(warnings) == 0 {
+		return
+	}
+
+	for i := range result.Nodes {
+		result.Nodes[i].Warnings = append(result.Nodes[i].Warnings, warnings[result.Nodes[i].Address]...)
+	}
+	result.Passed = len(result.FailedNodes) == 0 && len(result.Errors) == 0
+}
@@ -0,0 +1,133 @@
+package checker
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// priorityInfo returns the node config of a connector of upstream id at
+// address with the given priority
+func priorityInfo(id, address, priority string) config.NodeInfo {
+	return config.NodeInfo{ID: id, Chain: testChain, Address: address, Priority: priority}
+}
+
+func TestCheckChainPriorities(t *testing.T) {
+	tests := []struct {
+		name string
+		// primaryDown and fallbackDown close the connectors of upstream
+		// "infura", primaryForked and fallbackForked fork them
+		primaryDown, fallbackDown     bool
+		primaryForked, fallbackForked bool
+		passed                        bool
+		primaryCode                   ReasonCode
+		fallbackWarning               string
+	}{
+		{name: "all pass", passed: true},
+		{name: "fallback down", fallbackDown: true, passed: true, fallbackWarning: "fallback node failed: "},
+		{name: "primary down", primaryDown: true, primaryCode: ReasonConnection},
+		{name: "fallback forked", fallbackForked: true, passed: true, fallbackWarning: "fallback node failed: block hash mismatch"},
+		{name: "primary forked", primaryForked: true, primaryCode: ReasonHashMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector := func(down, forked bool) string {
+				if down {
+					return closedAddress(t)
+				}
+				m := newMockNode(t, 1, 1000)
+				if forked {
+					m.fork = 990
+				}
+				return m.server.URL
+			}
+
+			// The connectors of upstream infura share its ID
+			primary := priorityInfo("infura", connector(tt.primaryDown, tt.primaryForked), config.PriorityPrimary)
+			fallback := priorityInfo("infura", connector(tt.fallbackDown, tt.fallbackForked), config.PriorityFallback)
+			b := newMockNode(t, 1, 1000)
+			c := newMockNode(t, 1, 1000)
+
+			checker := newTestChecker(t, nil, testOptions())
+			result := checker.CheckChain(context.Background(), testChain, []config.NodeInfo{
+				primary, fallback, b.info("b"), c.info("c"),
+			})
+
+			if result.Passed != tt.passed {
+				t.Errorf("chain passed = %t, want %t: %+v", result.Passed, tt.passed, result.FailedNodes)
+			}
+
+			// Fallback failures are warnings, never failures
+			for _, fn := range result.FailedNodes {
+				if fn.Address == fallback.Address {
+					t.Errorf("fallback failed with %s: %s, want a warning", fn.Code, fn.Reason)
+				}
+				if fn.Address != primary.Address {
+					t.Errorf("node %s failed with %s: %s", fn.ID, fn.Code, fn.Reason)
+				}
+			}
+
+			primaryFailures := slices.DeleteFunc(slices.Clone(result.FailedNodes), func(fn FailedNode) bool {
+				return fn.Address != primary.Address
+			})
+			if tt.primaryCode == ReasonUnknown {
+				if len(primaryFailures) > 0 {
+					t.Errorf("primary failed with %+v, want pass", primaryFailures)
+				}
+			} else if !slices.ContainsFunc(primaryFailures, func(fn FailedNode) bool {
+				return fn.Code == tt.primaryCode && fn.Priority == config.PriorityPrimary
+			}) {
+				t.Errorf("primary failures = %+v, want %s with priority primary", primaryFailures, tt.primaryCode)
+			}
+
+			warnings := result.Nodes[1].Warnings
+			if tt.fallbackWarning == "" {
+				if slices.ContainsFunc(warnings, func(w string) bool { return strings.HasPrefix(w, "fallback node failed") }) {
+					t.Errorf("fallback warnings = %v, want no failure", warnings)
+				}
+			} else if !slices.ContainsFunc(warnings, func(w string) bool { return strings.HasPrefix(w, tt.fallbackWarning) }) {
+				t.Errorf("fallback warnings = %v, want %q", warnings, tt.fallbackWarning)
+			}
+			if slices.ContainsFunc(result.Nodes[0].Warnings, func(w string) bool { return strings.HasPrefix(w, "fallback node failed") }) {
+				t.Errorf("primary warnings = %v, want no fallback failure", result.Nodes[0].Warnings)
+			}
+		})
+	}
+}
+
+func TestApplyPriorities(t *testing.T) {
+	result := ChainResult{
+		Nodes: []NodeResult{
+			{ID: "infura", Address: "https://primary.example.com", Priority: config.PriorityPrimary},
+			{ID: "infura", Address: "https://fallback.example.com", Priority: config.PriorityFallback},
+			{ID: "other", Address: "https://other.example.com"},
+		},
+		FailedNodes: []FailedNode{
+			{ID: "infura", Address: "https://primary.example.com", Code: ReasonBlockGap, Reason: "block gap too large"},
+			{ID: "infura", Address: "https://fallback.example.com", Code: ReasonSyncing, Reason: "node is still syncing"},
+			{ID: "other", Address: "https://other.example.com", Code: ReasonPeerCount, Reason: "peer count too low"},
+		},
+	}
+
+	applyPriorities(&result)
+
+	if len(result.FailedNodes) != 2 {
+		t.Fatalf("failed nodes = %+v, want primary and other", result.FailedNodes)
+	}
+	if fn := result.FailedNodes[0]; fn.Code != ReasonBlockGap || fn.Priority != config.PriorityPrimary {
+		t.Errorf("primary failure = %+v, want block gap with priority primary", fn)
+	}
+	if fn := result.FailedNodes[1]; fn.Code != ReasonPeerCount || fn.Priority != "" {
+		t.Errorf("other failure = %+v, want peer count without priority", fn)
+	}
+	if want := []string{"fallback node failed: node is still syncing"}; !slices.Equal(result.Nodes[1].Warnings, want) {
+		t.Errorf("fallback warnings = %v, want %v", result.Nodes[1].Warnings, want)
+	}
+	if len(result.Nodes[0].Warnings) > 0 || len(result.Nodes[2].Warnings) > 0 {
+		t.Errorf("warnings of other nodes = %v and %v, want none", result.Nodes[0].Warnings, result.Nodes[2].Warnings)
+	}
+}
@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+	"golang.org/x/net/publicsuffix"
+)
+
+// providerOf approximates the provider serving a node by the registrable
+// domain of its host name, e.g. "infura.io" for mainnet.infura.io and
+// "example.co.uk" for eth.example.co.uk. IP addresses and hosts without a
+// registrable domain, such as localhost, are their own provider.
+func providerOf(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+
+	return domain
+}
+
+// checkProviderDiversity reports a chain whose nodes are all served by the
+// same provider, since an outage of the provider takes down the whole
+// chain. Chains with a single node are skipped, they can't be diverse. It
+// is a warning unless Options.RequireDiversity is set.
+func (c *Checker) checkProviderDiversity(result *ChainResult, nodes []config.NodeInfo) {
+	if len(nodes) < 2 {
+		return
+	}
+
+	provider := providerOf(nodes[0].Address)
+	for _, node := range nodes[1:] {
+		if providerOf(node.Address) != provider {
+			return
+		}
+	}
+
+	reason := fmt.Sprintf("single provider: all %d nodes are served by %s", len(nodes), provider)
+	if !c.opts.RequireDiversity {
+		result.Warnings = append(result.Warnings, reason)
+		return
+	}
+
+	result.Errors = append(result.Errors, reason)
+	result.Passed = false
+}
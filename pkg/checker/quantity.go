@@ -0,0 +1,96 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// getChainID returns the chain ID of a node. If ethclient rejects the
+// response, the chain ID is read with a raw eth_chainId call instead.
+func (c *Checker) getChainID(ctx context.Context, rpcClient *rpc.Client) (*big.Int, error) {
+	chainID, err := ethclient.NewClient(rpcClient).ChainID(ctx)
+	if err == nil {
+		return chainID, nil
+	}
+
+	return c.rawQuantityFallback(ctx, rpcClient, "eth_chainId", err)
+}
+
+// getBlockNumber returns the head block number of a node. If ethclient
+// rejects the response, it is read with a raw eth_blockNumber call instead.
+func (c *Checker) getBlockNumber(ctx context.Context, rpcClient *rpc.Client) (uint64, error) {
+	blockNumber, err := ethclient.NewClient(rpcClient).BlockNumber(ctx)
+	if err == nil {
+		return blockNumber, nil
+	}
+
+	number, err := c.rawQuantityFallback(ctx, rpcClient, "eth_blockNumber", err)
+	if err != nil {
+		return 0, err
+	}
+	if !number.IsUint64() {
+		return 0, fmt.Errorf("block number %s out of range", number)
+	}
+
+	return number.Uint64(), nil
+}
+
+// rawQuantityFallback calls method without ethclient after it failed with
+// err. Connection errors are returned as is since the raw call would fail
+// the same way. If the raw call fails too, err is returned.
+func (c *Checker) rawQuantityFallback(ctx context.Context, rpcClient *rpc.Client, method string, err error) (*big.Int, error) {
+	var rpcErr rpc.Error
+	if kind, _ := connectErrorKind(err); kind != "" || errors.As(err, &rpcErr) || ctx.Err() != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if rawErr := rpcClient.CallContext(ctx, &raw, method); rawErr != nil {
+		return nil, err
+	}
+
+	value, parseErr := parseQuantity(raw)
+	if parseErr != nil {
+		return nil, err
+	}
+
+	c.log(ctx).Warn("node returned an off-spec response, used raw call",
+		"method", method,
+		"error", err)
+
+	return value, nil
+}
+
+// parseQuantity leniently parses a quantity returned by an off-spec node.
+// Strings are hex, with or without the 0x prefix and with leading zeros;
+// JSON numbers are decimal.
+func parseQuantity(raw json.RawMessage) (*big.Int, error) {
+	var s string
+	base := 16
+	if err := json.Unmarshal(raw, &s); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return nil, fmt.Errorf("invalid quantity %s", raw)
+		}
+		s, base = number.String(), 10
+	}
+
+	s = strings.TrimSpace(s)
+	if base == 16 {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	}
+
+	value, ok := new(big.Int).SetString(s, base)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %s", raw)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,32 @@
+package checker
+
+// applyQuorum passes a chain whose only failures are failed nodes as long as
+// enough of its nodes are healthy: at most Options.MaxFailedPerChain nodes
+// failed and at least Options.MinHealthyRatio of the nodes passed. Without
+// either option every failed node fails the chain. The failures are still
+// reported and the chain is marked as degraded.
+func (c *Checker) applyQuorum(result *ChainResult) {
+	if c.opts.MaxFailedPerChain <= 0 && c.opts.MinHealthyRatio <= 0 {
+		return
+	}
+	if result.Passed || len(result.Errors) > 0 || len(result.Nodes) == 0 {
+		return
+	}
+
+	failed := make(map[string]bool)
+	for _, fn := range result.FailedNodes {
+		failed[fn.Address] = true
+	}
+
+	if c.opts.MaxFailedPerChain > 0 && len(failed) > c.opts.MaxFailedPerChain {
+		return
+	}
+
+	healthy := float64(len(result.Nodes)-len(failed)) / float64(len(result.Nodes))
+	if c.opts.MinHealthyRatio > 0 && healthy < c.opts.MinHealthyRatio {
+		return
+	}
+
+	result.Passed = true
+	result.Degraded = true
+}
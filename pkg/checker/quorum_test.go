@@ -0,0 +1,104 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// quorumResult returns a failed chain result of total nodes, the first
+// failed of which failed
+func quorumResult(total, failed int) ChainResult {
+	result := ChainResult{Chain: testChain, Passed: failed == 0}
+	for i := range total {
+		address := fmt.Sprintf("https://node-%d.example.com", i)
+		result.Nodes = append(result.Nodes, NodeResult{ID: fmt.Sprintf("node-%d", i), Chain: testChain, Address: address})
+		if i < failed {
+			// A node failing several checks is counted once
+			for _, code := range []ReasonCode{ReasonBlockGap, ReasonPeerCount} {
+				result.FailedNodes = append(result.FailedNodes, FailedNode{ID: fmt.Sprintf("node-%d", i), Chain: testChain, Address: address, Code: code})
+			}
+		}
+	}
+	return result
+}
+
+func TestApplyQuorum(t *testing.T) {
+	tests := []struct {
+		name      string
+		failed    int
+		maxFailed int
+		ratio     float64
+		errors    []string
+		passed    bool
+	}{
+		{name: "no options", failed: 1},
+		{name: "1 of 10 with ratio 0.8", failed: 1, ratio: 0.8, passed: true},
+		{name: "2 of 10 with ratio 0.8", failed: 2, ratio: 0.8, passed: true},
+		{name: "3 of 10 with ratio 0.8", failed: 3, ratio: 0.8},
+		{name: "1 of 10 with max 1", failed: 1, maxFailed: 1, passed: true},
+		{name: "2 of 10 with max 1", failed: 2, maxFailed: 1},
+		{name: "both options met", failed: 2, maxFailed: 2, ratio: 0.8, passed: true},
+		{name: "max met, ratio not", failed: 3, maxFailed: 3, ratio: 0.8},
+		{name: "ratio met, max not", failed: 2, maxFailed: 1, ratio: 0.8},
+		{name: "chain errors always fail", failed: 1, ratio: 0.8, errors: []string{"chain ID split"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			opts.MaxFailedPerChain = tt.maxFailed
+			opts.MinHealthyRatio = tt.ratio
+			c := newTestChecker(t, nil, opts)
+
+			result := quorumResult(10, tt.failed)
+			result.Errors = tt.errors
+			c.applyQuorum(&result)
+
+			if result.Passed != tt.passed || result.Degraded != tt.passed {
+				t.Errorf("passed = %t, degraded = %t, want %t", result.Passed, result.Degraded, tt.passed)
+			}
+			// Failures are reported either way
+			if len(result.FailedNodes) != 2*tt.failed {
+				t.Errorf("got %d failures, want %d", len(result.FailedNodes), 2*tt.failed)
+			}
+		})
+	}
+}
+
+func TestCheckChainQuorum(t *testing.T) {
+	for _, tt := range []struct {
+		lagging int
+		passed  bool
+	}{
+		{lagging: 1, passed: true},
+		{lagging: 3, passed: false},
+	} {
+		t.Run(fmt.Sprintf("%d of 10 failing", tt.lagging), func(t *testing.T) {
+			var nodes []config.NodeInfo
+			for i := range 10 {
+				head := uint64(1000)
+				if i < tt.lagging {
+					head = 900
+				}
+				nodes = append(nodes, newMockNode(t, 1, head).info(fmt.Sprintf("node-%d", i)))
+			}
+
+			opts := testOptions()
+			opts.MinHealthyRatio = 0.8
+			c := newTestChecker(t, nil, opts)
+
+			result := c.CheckChain(context.Background(), testChain, nodes)
+			if result.Passed != tt.passed || result.Degraded != tt.passed {
+				t.Errorf("passed = %t, degraded = %t, want %t", result.Passed, result.Degraded, tt.passed)
+			}
+			for i := range tt.lagging {
+				if codes := failedCodes(result, fmt.Sprintf("node-%d", i)); len(codes) == 0 {
+					t.Errorf("lagging node-%d is not reported as failed", i)
+				}
+			}
+		})
+	}
+}
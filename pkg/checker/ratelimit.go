@@ -0,0 +1,37 @@
+package checker
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedTransport delays the requests to a node so that the requests
+// to all nodes together stay within the rate of the shared limiter
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+	logger  *slog.Logger
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reservation := t.limiter.Reserve()
+	if delay := reservation.Delay(); delay > 0 {
+		t.logger.Debug("request throttled by rate limit",
+			"delay", delay)
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			reservation.Cancel()
+			return nil, req.Context().Err()
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
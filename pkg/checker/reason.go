@@ -0,0 +1,113 @@
+package checker
+
+// ReasonCode identifies the check a node failed. FailedNode.Reason holds the
+// human readable details.
+type ReasonCode int
+
+const (
+	ReasonUnknown ReasonCode = iota
+	ReasonCancelled
+	ReasonConnection
+	ReasonRateLimited
+	ReasonChainIDMismatch
+	ReasonChainIDChanged
+	ReasonGenesisMismatch
+	ReasonNetVersionMismatch
+	ReasonSyncing
+	ReasonPeerCount
+	ReasonBlockGap
+	ReasonHeadStuck
+	ReasonReorg
+	ReasonDebugUnavailable
+	ReasonTxPoolUnavailable
+	ReasonGetLogsUnavailable
+	ReasonSmokeCallFailed
+	ReasonCustomCheckFailed
+	ReasonArchiveUnavailable
+	ReasonBlockInconsistent
+	ReasonTrustedPeerUnavailable
+	ReasonTrustedPeerMismatch
+	ReasonPinnedHashMismatch
+	ReasonHashMismatch
+	ReasonGasPrice
+	ReasonBaseFee
+	ReasonLowScore
+)
+
+func (r ReasonCode) String() string {
+	switch r {
+	case ReasonCancelled:
+		return "cancelled"
+	case ReasonConnection:
+		return "connection"
+	case ReasonRateLimited:
+		return "rate_limited"
+	case ReasonChainIDMismatch:
+		return "chain_id_mismatch"
+	case ReasonChainIDChanged:
+		return "chain_id_changed"
+	case ReasonGenesisMismatch:
+		return "genesis_mismatch"
+	case ReasonNetVersionMismatch:
+		return "net_version_mismatch"
+	case ReasonSyncing:
+		return "syncing"
+	case ReasonPeerCount:
+		return "peer_count"
+	case ReasonBlockGap:
+		return "block_gap"
+	case ReasonHeadStuck:
+		return "head_stuck"
+	case ReasonReorg:
+		return "reorg"
+	case ReasonDebugUnavailable:
+		return "debug_unavailable"
+	case ReasonTxPoolUnavailable:
+		return "txpool_unavailable"
+	case ReasonGetLogsUnavailable:
+		return "get_logs_unavailable"
+	case ReasonSmokeCallFailed:
+		return "smoke_call_failed"
+	case ReasonCustomCheckFailed:
+		return "custom_check_failed"
+	case ReasonArchiveUnavailable:
+		return "archive_unavailable"
+	case ReasonBlockInconsistent:
+		return "block_inconsistent"
+	case ReasonTrustedPeerUnavailable:
+		return "trusted_peer_unavailable"
+	case ReasonTrustedPeerMismatch:
+		return "trusted_peer_mismatch"
+	case ReasonPinnedHashMismatch:
+		return "pinned_hash_mismatch"
+	case ReasonHashMismatch:
+		return "hash_mismatch"
+	case ReasonGasPrice:
+		return "gas_price"
+	case ReasonBaseFee:
+		return "base_fee"
+	case ReasonLowScore:
+		return "low_score"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the code as its name so JSON output is readable
+func (r ReasonCode) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Category returns the failure category of the code
+func (r ReasonCode) Category() Category {
+	switch r {
+	case ReasonConnection:
+		return CategoryConnection
+	case ReasonChainIDMismatch, ReasonChainIDChanged, ReasonGenesisMismatch, ReasonNetVersionMismatch:
+		return CategoryChainID
+	case ReasonHashMismatch, ReasonTrustedPeerMismatch, ReasonPinnedHashMismatch, ReasonReorg:
+		return CategoryBlockHash
+	default:
+		return CategoryOther
+	}
+}
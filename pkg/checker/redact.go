@@ -0,0 +1,105 @@
+package checker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// redactedError hides the secrets of a node URL in the message of err
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns err with the secrets of address masked in its message
+func redactError(err error, address string) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := config.RedactSecrets(err.Error(), address)
+	if msg == err.Error() {
+		return err
+	}
+
+	return &redactedError{err: err, msg: msg}
+}
+
+// redactHandler masks the secrets of the configured URLs in log messages and
+// attributes. RPC errors often contain the full request URL.
+type redactHandler struct {
+	handler slog.Handler
+	urls    []string
+}
+
+func newRedactHandler(handler slog.Handler, urls []string) *redactHandler {
+	return &redactHandler{
+		handler: handler,
+		urls:    urls,
+	}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, h.redactAttr(a))
+	}
+
+	return newRedactHandler(h.handler.WithAttrs(redacted), h.urls)
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return newRedactHandler(h.handler.WithGroup(name), h.urls)
+}
+
+func (h *redactHandler) redactAttr(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redact(value.String()))
+	case slog.KindGroup:
+		attrs := value.Group()
+		redacted := make([]any, 0, len(attrs))
+		for _, ga := range attrs {
+			redacted = append(redacted, h.redactAttr(ga))
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(a.Key, h.redact(err.Error()))
+		}
+	}
+
+	return a
+}
+
+func (h *redactHandler) redact(s string) string {
+	for _, url := range h.urls {
+		s = config.RedactSecrets(s, url)
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DefaultChainRegistry returns the chain IDs of well-known chain names
+func DefaultChainRegistry() map[string]uint64 {
+	return map[string]uint64{
+		"ethereum":     1,
+		"sepolia":      11155111,
+		"holesky":      17000,
+		"hoodi":        560048,
+		"bsc":          56,
+		"bsc-testnet":  97,
+		"polygon":      137,
+		"polygon-amoy": 80002,
+		"arbitrum":     42161,
+		"optimism":     10,
+		"base":         8453,
+		"avalanche":    43114,
+		"gnosis":       100,
+		"fantom":       250,
+		"linea":        59144,
+		"scroll":       534352,
+		"zksync":       324,
+		"blast":        81457,
+		"mantle":       5000,
+		"celo":         42220,
+	}
+}
+
+// checkChainName fails the chain if its name is in Options.ChainRegistry with
+// a different chain ID than the nodes report. Unknown names are not checked.
+func (c *Checker) checkChainName(result *ChainResult) {
+	if result.ExpectedChainID == nil || !result.ExpectedChainID.IsUint64() {
+		return
+	}
+
+	expected, ok := c.opts.ChainRegistry[strings.ToLower(result.Chain)]
+	if !ok || expected == result.ExpectedChainID.Uint64() {
+		return
+	}
+
+	reported := result.ExpectedChainID.String()
+	if name := chainNameForID(c.opts.ChainRegistry, result.ExpectedChainID.Uint64()); name != "" {
+		reported += fmt.Sprintf(" (%s)", name)
+	}
+
+	result.Errors = append(result.Errors, fmt.Sprintf("chain name mismatch: config says '%s' but nodes report chain ID %s, expected %d",
+		result.Chain, reported, expected))
+	result.Passed = false
+}
+
+// chainNameForID returns the first name in registry, in sorted order, with
+// the given chain ID or an empty string
+func chainNameForID(registry map[string]uint64, chainID uint64) string {
+	for _, name := range slices.Sorted(maps.Keys(registry)) {
+		if registry[name] == chainID {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// reorgProbe is the first observation of the block checked for reorgs
+type reorgProbe struct {
+	header *blockHeader
+	time   time.Time
+}
+
+// startReorgCheck fetches the hash of the block Options.ReorgDepth blocks
+// below head. It returns nil if the block could not be fetched.
+func (c *Checker) startReorgCheck(ctx context.Context, rpcClient *rpc.Client, head uint64) *reorgProbe {
+	if head < c.opts.ReorgDepth {
+		return nil
+	}
+
+	start := time.Now()
+	header, err := getBlockHeader(ctx, rpcClient, fmt.Sprintf("0x%x", head-c.opts.ReorgDepth))
+	if err != nil {
+		c.log(ctx).Warn("failed to get block for reorg check",
+			"block", head-c.opts.ReorgDepth,
+			"error", err)
+		return nil
+	}
+
+	return &reorgProbe{header: header, time: start}
+}
+
+// finishReorgCheck waits until Options.ReorgDelay has passed since the probe
+// and fetches the block again. A different hash means the node reorged at
+// that depth.
+func (c *Checker) finishReorgCheck(ctx context.Context, rpcClient *rpc.Client, probe *reorgProbe, info *NodeResult) {
+	select {
+	case <-time.After(time.Until(probe.time.Add(c.opts.ReorgDelay))):
+	case <-ctx.Done():
+		return
+	}
+
+	start := time.Now()
+	header, err := getBlockHeader(ctx, rpcClient, fmt.Sprintf("0x%x", uint64(probe.header.Number)))
+	info.Timings.Reorg = time.Since(start)
+	if err != nil {
+		c.log(ctx).Warn("failed to get block for reorg check",
+			"block", uint64(probe.header.Number),
+			"error", err)
+		return
+	}
+
+	if header.Hash != probe.header.Hash {
+		info.Reorged = true
+		c.log(ctx).Debug("block hash changed between polls",
+			"block", uint64(probe.header.Number),
+			"first", probe.header.Hash.Hex(),
+			"second", header.Hash.Hex())
+	}
+}
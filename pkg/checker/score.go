@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reference values used to turn node signals into 0-1 sub-scores
+const (
+	scoreFastLatency = 500 * time.Millisecond // latency at or below scores 1
+	scoreSlowLatency = 5 * time.Second        // latency at or above scores 0
+	scoreTargetPeers = 10                     // peer count at or above scores 1
+)
+
+// ScoreWeights sets how much each signal contributes to a node's health
+// score. Weights are relative; the score is always between 0 and 100.
+//
+//   - Freshness: 1 / (1 + blocks behind the chain's highest block)
+//   - Latency: 1 up to 500ms total check time, falling linearly to 0 at 5s
+//   - Peers: peer count / 10, capped at 1. Unknown peer counts score 1
+//   - Debug: 1 if the debug API is available
+//   - Hashes: 1 if the node agrees with the majority block hashes
+type ScoreWeights struct {
+	Freshness float64
+	Latency   float64
+	Peers     float64
+	Debug     float64
+	Hashes    float64
+}
+
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{
+		Freshness: 30,
+		Latency:   20,
+		Peers:     15,
+		Debug:     15,
+		Hashes:    20,
+	}
+}
+
+// Score returns the health score of the node between 0 and 100. Nodes that
+// could not be checked score 0.
+func (n NodeResult) Score(w ScoreWeights) float64 {
+	total := w.Freshness + w.Latency + w.Peers + w.Debug + w.Hashes
+	if n.Error != nil || total <= 0 {
+		return 0
+	}
+
+	freshness := 1 / (1 + float64(n.BlockGap))
+
+	latency := 1 - float64(n.Timings.Total-scoreFastLatency)/float64(scoreSlowLatency-scoreFastLatency)
+	latency = min(max(latency, 0), 1)
+
+	peers := 1.0
+	if n.PeerCount != nil {
+		peers = min(float64(*n.PeerCount)/scoreTargetPeers, 1)
+	}
+
+	var debug, hashes float64
+	if n.DebugOK {
+		debug = 1
+	}
+	if !n.HashMismatch {
+		hashes = 1
+	}
+
+	score := w.Freshness*freshness + w.Latency*latency + w.Peers*peers + w.Debug*debug + w.Hashes*hashes
+
+	return 100 * score / total
+}
+
+// Score returns the average health score of the chain's nodes
+func (r ChainResult) Score(w ScoreWeights) float64 {
+	if len(r.Nodes) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, node := range r.Nodes {
+		sum += node.Score(w)
+	}
+
+	return sum / float64(len(r.Nodes))
+}
+
+// scoreNodes records the health score of every node and the chain, and fails
+// nodes below Options.MinScore
+func (c *Checker) scoreNodes(result *ChainResult) {
+	mismatched := make(map[string]bool)
+	for _, fn := range result.FailedNodes {
+		if fn.Code.Category() == CategoryBlockHash {
+			mismatched[fn.Address] = true
+		}
+	}
+
+	for i := range result.Nodes {
+		node := &result.Nodes[i]
+		node.HashMismatch = mismatched[node.Address]
+		node.Health = node.Score(c.opts.ScoreWeights)
+
+		if c.opts.MinScore > 0 && node.Health < c.opts.MinScore {
+			result.FailedNodes = append(result.FailedNodes, FailedNode{
+				ID:      node.ID,
+				Chain:   node.Chain,
+				Address: node.Address,
+				Code:    ReasonLowScore,
+				Reason:  fmt.Sprintf("health score too low: %.0f < %.0f", node.Health, c.opts.MinScore),
+			})
+			result.Passed = false
+		}
+	}
+
+	result.Health = result.Score(c.opts.ScoreWeights)
+}
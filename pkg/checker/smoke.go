@@ -0,0 +1,63 @@
+package checker
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// SmokeCallStatus holds the result of the eth_call configured for the chain.
+// Error holds the node's error message if the call failed.
+type SmokeCallStatus struct {
+	To     string `json:"to"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// checkSmokeCall calls the contract configured under smoke-call for the
+// node's chain at the latest block. Nodes can answer metadata requests while
+// failing to read state, which this call catches.
+func (c *Checker) checkSmokeCall(ctx context.Context, rpcClient *rpc.Client, call config.SmokeCall, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.SmokeCall = time.Since(start)
+	}()
+
+	status := &SmokeCallStatus{To: call.To}
+	info.SmokeCall = status
+
+	msg := map[string]string{
+		"to":   call.To,
+		"data": call.Data,
+	}
+
+	var result hexutil.Bytes
+	if err := rpcClient.CallContext(ctx, &result, "eth_call", msg, "latest"); err != nil {
+		status.Error = err.Error()
+		c.log(ctx).Debug("eth_call failed",
+			"to", call.To,
+			"error", err)
+		return
+	}
+
+	status.Result = result.String()
+}
+
+// smokeCallFailure returns why the smoke call result of a node is not
+// sensible, or an empty string if it is
+func smokeCallFailure(status *SmokeCallStatus, call config.SmokeCall) string {
+	switch {
+	case status.Error != "":
+		return "eth_call failed: " + status.Error
+	case status.Result == "" || status.Result == "0x":
+		return "eth_call returned empty result from " + call.To
+	case call.Expected != "" && !strings.EqualFold(status.Result, call.Expected):
+		return "eth_call returned " + status.Result + ", expected " + call.Expected
+	default:
+		return ""
+	}
+}
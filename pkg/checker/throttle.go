@@ -0,0 +1,106 @@
+package checker
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultRetryAfter is the wait before retrying a 429 response without
+	// a Retry-After header
+	defaultRetryAfter = time.Second
+
+	// maxRetryAfter is the longest Retry-After that is waited for, longer
+	// waits are reported as rate limited right away
+	maxRetryAfter = 10 * time.Second
+)
+
+// throttleTransport retries a request once after the Retry-After delay if a
+// provider answers with 429 Too Many Requests. A 429 response to the retry
+// marks the node as rate limited.
+type throttleTransport struct {
+	base        http.RoundTripper
+	logger      *slog.Logger
+	rateLimited *atomic.Bool
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Keep the body so that the request can be sent again
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok || delay > maxRetryAfter {
+		t.rateLimited.Store(true)
+		return resp, nil
+	}
+
+	t.logger.Debug("rate limited by provider, retrying",
+		"retry_after", delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-req.Context().Done():
+		t.rateLimited.Store(true)
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err = t.base.RoundTrip(retry)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.rateLimited.Store(true)
+	}
+
+	return resp, err
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, which is either
+// a number of seconds or an HTTP date. A missing header means
+// defaultRetryAfter, an invalid one is reported as not ok.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return defaultRetryAfter, true
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// httpClient returns the HTTP client for nodes with the given TLS settings.
+// Clients are shared between checks so that connections are reused.
+func (c *Checker) httpClient(t config.TLSConfig) (*http.Client, error) {
+	c.httpClientsMu.Lock()
+	defer c.httpClientsMu.Unlock()
+
+	if client, ok := c.httpClients[t]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := newTLSConfig(t)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	client := &http.Client{Transport: transport}
+	c.httpClients[t] = client
+
+	return client, nil
+}
+
+// newTLSConfig loads the client certificate and CA bundle of t. The CA
+// bundle is added to the system roots.
+func newTLSConfig(t config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,206 @@
+package checker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// testPKI is a CA with a server certificate for 127.0.0.1 and a client
+// certificate, written as PEM files to a temporary directory
+type testPKI struct {
+	pool   *x509.CertPool
+	server tls.Certificate
+
+	caFile, certFile, keyFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	dir := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// issue returns a certificate signed by the CA and its key as PEM
+	issue := func(serial int64, template *x509.Certificate) (certPEM, keyPEM []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.SerialNumber = big.NewInt(serial)
+		template.NotAfter = notAfter
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	serverCert, serverKey := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "node"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCert, clientKey := issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	pki := &testPKI{
+		pool:     x509.NewCertPool(),
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "client.pem"),
+		keyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	pki.pool.AddCert(ca)
+	pki.server, err = tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		pki.caFile:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pki.certFile: clientCert,
+		pki.keyFile:  clientKey,
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return pki
+}
+
+// newMTLSNode starts a node that requires a client certificate signed by
+// the CA of pki
+func newMTLSNode(t *testing.T, pki *testPKI) *mockNode {
+	t.Helper()
+
+	m := &mockNode{chainID: 1, head: 1000}
+	m.server = httptest.NewUnstartedServer(m)
+	m.server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.pool,
+	}
+	// Rejected handshakes are expected, don't log them
+	m.server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	m.server.StartTLS()
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func TestCheckNodeMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	node := newMTLSNode(t, pki)
+
+	clientTLS := &config.TLSConfig{CertFile: pki.certFile, KeyFile: pki.keyFile, CAFile: pki.caFile}
+	caOnly := &config.TLSConfig{CAFile: pki.caFile}
+
+	tests := []struct {
+		name      string
+		nodeTLS   *config.TLSConfig
+		clientTLS *config.TLSConfig
+		wantErr   bool
+	}{
+		{name: "connector tls", nodeTLS: clientTLS},
+		{name: "global tls", clientTLS: clientTLS},
+		{name: "connector tls over global tls", nodeTLS: clientTLS, clientTLS: caOnly},
+		{name: "no client certificate", nodeTLS: caOnly, wantErr: true},
+		{name: "unknown CA", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			opts.ClientTLS = tt.clientTLS
+			c := newTestChecker(t, nil, opts)
+
+			info := node.info("a")
+			info.TLS = tt.nodeTLS
+
+			result := c.CheckNode(context.Background(), info)
+			if (result.Error != nil) != tt.wantErr {
+				t.Fatalf("CheckNode error = %v, want error %t", result.Error, tt.wantErr)
+			}
+			if result.Error == nil && result.BlockNumber != 1000 {
+				t.Errorf("block number = %d, want 1000", result.BlockNumber)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	dir := t.TempDir()
+
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tls  config.TLSConfig
+		want string
+	}{
+		{name: "missing key", tls: config.TLSConfig{CertFile: pki.certFile, KeyFile: filepath.Join(dir, "missing.pem")}, want: "failed to load client certificate"},
+		{name: "key of another certificate", tls: config.TLSConfig{CertFile: pki.certFile, KeyFile: pki.caFile}, want: "failed to load client certificate"},
+		{name: "missing CA", tls: config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}, want: "failed to read CA bundle"},
+		{name: "CA without certificates", tls: config.TLSConfig{CAFile: notPEM}, want: "no certificates found in CA bundle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTLSConfig(tt.tls)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("newTLSConfig error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
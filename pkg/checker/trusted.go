@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// trustedNodes returns the responding nodes marked as trusted in the config.
+// Their chain ID, head and block hashes are the baseline of the chain.
+func trustedNodes(nodes []NodeResult) []NodeResult {
+	var trusted []NodeResult
+	for _, node := range nodes {
+		if node.Trusted && node.Error == nil {
+			trusted = append(trusted, node)
+		}
+	}
+	return trusted
+}
+
+// baselineNodes returns the reference node of the chain if there is one, and
+// the trusted nodes otherwise
+func (r *ChainResult) baselineNodes() []NodeResult {
+	if r.Reference != nil {
+		return []NodeResult{*r.Reference}
+	}
+	return trustedNodes(r.Nodes)
+}
+
+// baselineHash returns the hash the trusted nodes agree on. If they report
+// different hashes the chain fails and no baseline is returned, so the block
+// falls back to majority vote. The block is described by label.
+func baselineHash(result *ChainResult, trusted map[common.Hash][]string, label string) *common.Hash {
+	switch len(trusted) {
+	case 0:
+		return nil
+	case 1:
+		for hash := range trusted {
+			return &hash
+		}
+	}
+
+	hashes := slices.SortedFunc(maps.Keys(trusted), func(a, b common.Hash) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	parts := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		parts = append(parts, fmt.Sprintf("%s (%s)", hash.Hex(), strings.Join(trusted[hash], ", ")))
+	}
+
+	result.Errors = append(result.Errors, fmt.Sprintf("trusted nodes disagree on block hash at %s: %s", label, strings.Join(parts, ", ")))
+	result.Passed = false
+
+	return nil
+}
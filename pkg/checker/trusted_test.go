@@ -0,0 +1,196 @@
+package checker
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/evm-node-check/pkg/config"
+)
+
+// trustedInfo returns the node config of m marked as trusted
+func trustedInfo(m *mockNode, id string) config.NodeInfo {
+	info := m.info(id)
+	info.Trusted = true
+	return info
+}
+
+func hasError(result ChainResult, prefix string) bool {
+	return slices.ContainsFunc(result.Errors, func(e string) bool {
+		return strings.HasPrefix(e, prefix)
+	})
+}
+
+func TestCheckChainTrustedBaseline(t *testing.T) {
+	trusted := newMockNode(t, 1, 1000)
+	a := newMockNode(t, 1, 1000)
+	a.fork = 900
+	b := newMockNode(t, 1, 1000)
+	b.fork = 900
+	honest := newMockNode(t, 1, 1000)
+
+	c := newTestChecker(t, nil, testOptions())
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		trustedInfo(trusted, "trusted"), a.info("a"), b.info("b"), honest.info("honest"),
+	})
+
+	// The forked nodes are two of four, but the trusted node decides
+	for _, id := range []string{"a", "b"} {
+		if codes := failedCodes(result, id); !slices.Contains(codes, ReasonHashMismatch) {
+			t.Errorf("node %s failed with %v, want %s", id, codes, ReasonHashMismatch)
+		}
+	}
+	for _, id := range []string{"trusted", "honest"} {
+		if codes := failedCodes(result, id); len(codes) > 0 {
+			t.Errorf("node %s failed with %v, want pass", id, codes)
+		}
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("errors = %v, want none", result.Errors)
+	}
+}
+
+func TestCheckChainTrustedChainID(t *testing.T) {
+	trusted := newMockNode(t, 1, 1000)
+	a := newMockNode(t, 56, 1000)
+	b := newMockNode(t, 56, 1000)
+
+	c := newTestChecker(t, nil, testOptions())
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+		a.info("a"), b.info("b"), trustedInfo(trusted, "trusted"),
+	})
+
+	if result.ExpectedChainID.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("expected chain ID = %v, want 1 of the trusted node", result.ExpectedChainID)
+	}
+	for _, id := range []string{"a", "b"} {
+		if codes := failedCodes(result, id); !slices.Contains(codes, ReasonChainIDMismatch) {
+			t.Errorf("node %s failed with %v, want %s", id, codes, ReasonChainIDMismatch)
+		}
+	}
+	if codes := failedCodes(result, "trusted"); len(codes) > 0 {
+		t.Errorf("trusted node failed with %v, want pass", codes)
+	}
+}
+
+func TestCheckChainTrustedConflict(t *testing.T) {
+	t.Run("block hash", func(t *testing.T) {
+		first := newMockNode(t, 1, 1000)
+		second := newMockNode(t, 1, 1000)
+		second.fork = 900
+		other := newMockNode(t, 1, 1000)
+
+		c := newTestChecker(t, nil, testOptions())
+		result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+			trustedInfo(first, "first"), trustedInfo(second, "second"), other.info("other"),
+		})
+
+		if result.Passed {
+			t.Error("chain passed with trusted nodes disagreeing")
+		}
+		if !hasError(result, "trusted nodes disagree on block hash") {
+			t.Errorf("errors = %v, want trusted nodes disagree on block hash", result.Errors)
+		}
+
+		// Without a baseline the block falls back to majority vote
+		if codes := failedCodes(result, "second"); !slices.Contains(codes, ReasonHashMismatch) {
+			t.Errorf("second failed with %v, want %s by majority", codes, ReasonHashMismatch)
+		}
+	})
+
+	t.Run("chain ID", func(t *testing.T) {
+		first := newMockNode(t, 1, 1000)
+		second := newMockNode(t, 56, 1000)
+		a := newMockNode(t, 56, 1000)
+		b := newMockNode(t, 56, 1000)
+
+		c := newTestChecker(t, nil, testOptions())
+		result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{
+			trustedInfo(first, "first"), trustedInfo(second, "second"), a.info("a"), b.info("b"),
+		})
+
+		if result.Passed {
+			t.Error("chain passed with trusted nodes disagreeing")
+		}
+		if !hasError(result, "trusted nodes disagree on chain ID") {
+			t.Errorf("errors = %v, want trusted nodes disagree on chain ID", result.Errors)
+		}
+
+		// Without a baseline the chain ID falls back to majority vote
+		if result.ExpectedChainID.Cmp(big.NewInt(56)) != 0 {
+			t.Errorf("expected chain ID = %v, want 56 by majority", result.ExpectedChainID)
+		}
+		if codes := failedCodes(result, "first"); !slices.Contains(codes, ReasonChainIDMismatch) {
+			t.Errorf("first failed with %v, want %s", codes, ReasonChainIDMismatch)
+		}
+	})
+}
+
+func TestCheckChainReference(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup configures the reference node
+		setup func(ref *mockNode)
+		code  ReasonCode
+	}{
+		{name: "agreement", setup: func(ref *mockNode) {}},
+		{name: "hash divergence", setup: func(ref *mockNode) { ref.fork = 900 }, code: ReasonHashMismatch},
+		{name: "chain ID divergence", setup: func(ref *mockNode) { ref.chainID = 5 }, code: ReasonChainIDMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := newMockNode(t, 1, 1000)
+			tt.setup(ref)
+
+			var nodes []config.NodeInfo
+			for _, id := range []string{"a", "b", "c"} {
+				nodes = append(nodes, newMockNode(t, 1, 1000).info(id))
+			}
+
+			opts := testOptions()
+			opts.References = map[string]string{testChain: ref.server.URL}
+			c := newTestChecker(t, nil, opts)
+
+			result := c.CheckChain(context.Background(), testChain, nodes)
+			if result.Reference == nil {
+				t.Fatalf("no reference result, errors %v", result.Errors)
+			}
+			if len(result.Errors) > 0 {
+				t.Errorf("errors = %v, want none", result.Errors)
+			}
+
+			// The nodes agree with each other, but the reference decides
+			for _, node := range nodes {
+				codes := failedCodes(result, node.ID)
+				switch {
+				case tt.code == 0 && len(codes) > 0:
+					t.Errorf("node %s failed with %v, want pass", node.ID, codes)
+				case tt.code != 0 && !slices.Contains(codes, tt.code):
+					t.Errorf("node %s failed with %v, want %s", node.ID, codes, tt.code)
+				}
+			}
+			if result.Passed != (tt.code == 0) {
+				t.Errorf("passed = %t, want %t", result.Passed, tt.code == 0)
+			}
+		})
+	}
+}
+
+func TestCheckChainReferenceDown(t *testing.T) {
+	node := newMockNode(t, 1, 1000)
+
+	opts := testOptions()
+	opts.References = map[string]string{testChain: closedAddress(t)}
+	c := newTestChecker(t, nil, opts)
+
+	result := c.CheckChain(context.Background(), testChain, []config.NodeInfo{node.info("a")})
+	if result.Passed {
+		t.Error("chain passed without its reference node")
+	}
+	if !hasError(result, "reference node error") {
+		t.Errorf("errors = %v, want reference node error", result.Errors)
+	}
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TxPoolStatus holds the transaction pool state reported by a node
+type TxPoolStatus struct {
+	Method  string `json:"method"`
+	Pending uint64 `json:"pending"`
+	Queued  uint64 `json:"queued"`
+}
+
+// txPoolContent maps pending/queued -> sender -> nonce -> transaction
+type txPoolContent map[string]map[string]map[string]json.RawMessage
+
+// checkTxPool probes the transaction pool with txpool_status, falling back
+// to txpool_content for clients that only expose the content method
+func (c *Checker) checkTxPool(ctx context.Context, rpcClient *rpc.Client, info *NodeResult) {
+	start := time.Now()
+	defer func() {
+		info.Timings.TxPool = time.Since(start)
+	}()
+
+	var status struct {
+		Pending hexutil.Uint64 `json:"pending"`
+		Queued  hexutil.Uint64 `json:"queued"`
+	}
+	err := rpcClient.CallContext(ctx, &status, "txpool_status")
+	if err == nil {
+		info.TxPool = &TxPoolStatus{
+			Method:  "txpool_status",
+			Pending: uint64(status.Pending),
+			Queued:  uint64(status.Queued),
+		}
+		return
+	}
+
+	c.log(ctx).Debug("txpool_status failed, trying txpool_content",
+		"error", err)
+
+	var content txPoolContent
+	if err := rpcClient.CallContext(ctx, &content, "txpool_content"); err != nil {
+		c.log(ctx).Debug("txpool_content failed",
+			"error", err)
+		return
+	}
+
+	info.TxPool = &TxPoolStatus{
+		Method:  "txpool_content",
+		Pending: content.count("pending"),
+		Queued:  content.count("queued"),
+	}
+}
+
+func (c txPoolContent) count(pool string) uint64 {
+	var total uint64
+	for _, txs := range c[pool] {
+		total += uint64(len(txs))
+	}
+	return total
+}
@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"cmp"
+	"slices"
+)
+
+// UpstreamSummary counts the nodes of an upstream, e.g. one provider, within
+// a chain. Connectors of an upstream share its ID.
+type UpstreamSummary struct {
+	ID          string `json:"id"`
+	Chain       string `json:"chain"`
+	TotalNodes  int    `json:"total_nodes"`
+	PassedNodes int    `json:"passed_nodes"`
+	FailedNodes int    `json:"failed_nodes"`
+}
+
+// Upstreams summarizes the nodes of the chain per upstream ID, sorted by ID
+func (r ChainResult) Upstreams() []UpstreamSummary {
+	failed := make(map[string]bool)
+	for _, fn := range r.FailedNodes {
+		failed[fn.Address] = true
+	}
+
+	var upstreams []UpstreamSummary
+	for _, node := range r.Nodes {
+		idx := slices.IndexFunc(upstreams, func(u UpstreamSummary) bool { return u.ID == node.ID })
+		if idx < 0 {
+			upstreams = append(upstreams, UpstreamSummary{ID: node.ID, Chain: r.Chain})
+			idx = len(upstreams) - 1
+		}
+
+		upstreams[idx].TotalNodes++
+		if failed[node.Address] {
+			upstreams[idx].FailedNodes++
+		} else {
+			upstreams[idx].PassedNodes++
+		}
+	}
+
+	slices.SortFunc(upstreams, func(a, b UpstreamSummary) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return upstreams
+}
+
+// Upstreams summarizes the nodes of every chain per upstream ID, sorted by
+// chain and ID
+func (r *CheckResult) Upstreams() []UpstreamSummary {
+	var upstreams []UpstreamSummary
+	for _, chainResult := range r.ChainResults {
+		upstreams = append(upstreams, chainResult.Upstreams()...)
+	}
+	return upstreams
+}
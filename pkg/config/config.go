@@ -0,0 +1,719 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	UpstreamConfig UpstreamConfig         `yaml:"upstream-config"`
+	Nodes          []FlatNode             `yaml:"nodes"`
+	Chains         map[string]ChainConfig `yaml:"chains"`
+
+	// Warnings holds non-fatal problems found while loading the config
+	Warnings []string `yaml:"-"`
+}
+
+// LoadOptions controls how strictly the config is validated
+type LoadOptions struct {
+	// AllowDuplicateURLs reports duplicate connector URLs as warnings
+	// instead of errors
+	AllowDuplicateURLs bool
+
+	// StrictConnectorTypes reports connectors of unknown types as errors
+	// instead of warnings
+	StrictConnectorTypes bool
+
+	// Nodes are added to the nodes of the config files, e.g. nodes given on
+	// the command line
+	Nodes []FlatNode
+}
+
+// ChainConfig holds optional per-chain settings keyed by chain name
+type ChainConfig struct {
+	TrustedPeer      string       `yaml:"trusted-peer"`
+	MaxBlockGap      *uint64      `yaml:"max-block-gap"`
+	BlockHashCount   *int         `yaml:"block-hash-count"`
+	DebugUnsupported bool         `yaml:"debug-unsupported"`
+	SmokeCall        *SmokeCall   `yaml:"smoke-call"`
+	PinnedHashes     []PinnedHash `yaml:"pinned-hashes"`
+}
+
+// PinnedHash is the known hash of a block, e.g. a checkpoint, that every
+// node of a chain or upstream must serve
+type PinnedHash struct {
+	Block uint64 `yaml:"block"`
+	Hash  string `yaml:"hash"`
+}
+
+// UnmarshalYAML rejects block numbers that are not integers, which yaml.v3
+// would otherwise truncate, e.g. 1.5 to block 1. Decoding a node doesn't
+// reject unknown fields, so they are checked here as well.
+func (p *PinnedHash) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			switch key.Value {
+			case "block":
+				if val.ShortTag() != "!!int" {
+					return fmt.Errorf("line %d: pinned block must be a block number, got %q", val.Line, val.Value)
+				}
+			case "hash":
+			default:
+				return fmt.Errorf("line %d: field %s not found in type config.PinnedHash", key.Line, key.Value)
+			}
+		}
+	}
+
+	type pinnedHash PinnedHash
+	return value.Decode((*pinnedHash)(p))
+}
+
+// validatePinnedHashes checks that every pinned hash is a 32 byte hex hash
+// and that no block is pinned twice
+func validatePinnedHashes(pins []PinnedHash) error {
+	seen := make(map[uint64]bool, len(pins))
+	for _, pin := range pins {
+		if !isHex(pin.Hash) || len(pin.Hash) != 66 {
+			return fmt.Errorf("pinned hash of block %d must be a 32 byte hex hash, got %q", pin.Block, pin.Hash)
+		}
+		if seen[pin.Block] {
+			return fmt.Errorf("block %d is pinned twice", pin.Block)
+		}
+		seen[pin.Block] = true
+	}
+	return nil
+}
+
+// mergePinnedHashes returns the pinned hashes of a chain and an upstream
+// sorted by block. Pins of the upstream replace those of the chain at the
+// same block.
+func mergePinnedHashes(chain, upstream []PinnedHash) []PinnedHash {
+	if len(chain) == 0 && len(upstream) == 0 {
+		return nil
+	}
+
+	byBlock := make(map[uint64]PinnedHash, len(chain)+len(upstream))
+	for _, pin := range chain {
+		byBlock[pin.Block] = pin
+	}
+	for _, pin := range upstream {
+		byBlock[pin.Block] = pin
+	}
+
+	return slices.SortedFunc(maps.Values(byBlock), func(a, b PinnedHash) int {
+		return cmp.Compare(a.Block, b.Block)
+	})
+}
+
+// SmokeCall is an eth_call to a well-known contract that every node of a
+// chain must answer, e.g. the decimals() of a stablecoin
+type SmokeCall struct {
+	To       string `yaml:"to"`
+	Data     string `yaml:"data"`
+	Expected string `yaml:"expected"`
+}
+
+// Validate checks that the call has a contract address and hex encoded
+// calldata and expected result
+func (s SmokeCall) Validate() error {
+	if !isHex(s.To) || len(s.To) != 42 {
+		return fmt.Errorf("smoke-call to must be a hex address, got %q", s.To)
+	}
+	if !isHex(s.Data) {
+		return fmt.Errorf("smoke-call data must be hex encoded, got %q", s.Data)
+	}
+	if s.Expected != "" && !isHex(s.Expected) {
+		return fmt.Errorf("smoke-call expected must be hex encoded, got %q", s.Expected)
+	}
+	return nil
+}
+
+// isHex reports whether s is a 0x prefixed hex string
+func isHex(s string) bool {
+	digits, ok := strings.CutPrefix(s, "0x")
+	if !ok {
+		return false
+	}
+	for _, r := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+type UpstreamConfig struct {
+	Upstreams []Upstream `yaml:"upstreams"`
+}
+
+type Upstream struct {
+	ID               string       `yaml:"id"`
+	Chain            string       `yaml:"chain"`
+	MaxBlockGap      *uint64      `yaml:"max-block-gap"`
+	Trusted          bool         `yaml:"trusted"`
+	DebugUnsupported bool         `yaml:"debug-unsupported"`
+	AcceptedChainIDs []uint64     `yaml:"accepted-chain-ids"`
+	PinnedHashes     []PinnedHash `yaml:"pinned-hashes"`
+	Connectors       []Connector  `yaml:"connectors"`
+}
+
+// FlatNode is a node of the simpler top-level nodes list. Every node is
+// normalized into an upstream with a single json-rpc connector.
+type FlatNode struct {
+	ID      string            `yaml:"id"`
+	Chain   string            `yaml:"chain"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Connector types of eProxy configs. Only json-rpc connectors are checked,
+// Load warns about the others.
+var knownConnectorTypes = []string{"json-rpc", "ws", "ipc"}
+
+// checkedConnectorType is the only connector type that is checked
+const checkedConnectorType = "json-rpc"
+
+// connectorTypeProblem returns why a connector of type t is not checked, or
+// an empty string if it is checked. Load reports the problem as a warning,
+// or as an error if strict is set and LoadOptions.StrictConnectorTypes too,
+// so that Lint never rejects a connector that Load accepts.
+func connectorTypeProblem(t string) (problem string, strict bool) {
+	if t == checkedConnectorType {
+		return "", false
+	}
+
+	// Other eProxy types are valid, they are just not checked
+	if slices.Contains(knownConnectorTypes, t) {
+		return fmt.Sprintf("%s connector, only %s connectors are checked", t, checkedConnectorType), false
+	}
+
+	// Connectors of unknown types, e.g. a misspelled "jsonrpc", are never
+	// checked
+	return fmt.Sprintf("connector with unknown type %q", t), true
+}
+
+type Connector struct {
+	Type        string            `yaml:"type"`
+	URL         string            `yaml:"url"`
+	Headers     map[string]string `yaml:"headers"`
+	MaxBlockGap *uint64           `yaml:"max-block-gap"`
+	TLS         *TLSConfig        `yaml:"tls"`
+	Trusted     bool              `yaml:"trusted"`
+	Priority    string            `yaml:"priority"`
+}
+
+// Connector priorities. Failures of primary connectors are reported with
+// elevated severity, failures of fallback connectors only as warnings.
+// Connectors without a priority are reported as usual.
+const (
+	PriorityPrimary  = "primary"
+	PriorityFallback = "fallback"
+)
+
+// TLSConfig holds the client certificate and CA bundle used to connect to
+// https endpoints that require mutual TLS
+type TLSConfig struct {
+	CertFile string `yaml:"cert-file"`
+	KeyFile  string `yaml:"key-file"`
+	CAFile   string `yaml:"ca-file"`
+}
+
+// Validate checks that the certificate and key are set together
+func (t TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("tls cert-file and key-file must be set together")
+	}
+	if t.CertFile == "" && t.CAFile == "" {
+		return errors.New("tls requires cert-file and key-file or ca-file")
+	}
+	return nil
+}
+
+// NodeInfo is a flattened representation for the checker
+type NodeInfo struct {
+	ID          string
+	Chain       string
+	Address     string
+	Headers     map[string]string
+	MaxBlockGap *uint64
+	TLS         *TLSConfig
+
+	// Trusted nodes are the baseline the other nodes of the chain are
+	// compared against
+	Trusted bool
+
+	// DebugUnsupported skips the debug check of the node
+	DebugUnsupported bool
+
+	// AcceptedChainIDs are the chain IDs the node may report instead of the
+	// expected chain ID of its chain
+	AcceptedChainIDs []uint64
+
+	// Priority is PriorityPrimary, PriorityFallback or empty
+	Priority string
+
+	// PinnedHashes are the block hashes the node must serve, from the
+	// settings of its chain and upstream
+	PinnedHashes []PinnedHash
+}
+
+const (
+	// configFetchTimeout limits fetching a config from a URL
+	configFetchTimeout = 30 * time.Second
+
+	// maxConfigFetchSize limits the size of a config fetched from a URL
+	maxConfigFetchSize = 1 << 20
+)
+
+// Load reads the config from a file path, from stdin if path is "-", or from
+// an http(s) URL
+func Load(path string, opts LoadOptions) (*Config, error) {
+	return LoadFiles([]string{path}, opts)
+}
+
+// LoadFiles reads several configs like Load and merges their upstreams and
+// chain settings into one config. Upstream IDs and chain settings must not be
+// repeated across files.
+func LoadFiles(paths []string, opts LoadOptions) (*Config, error) {
+	merged := Config{
+		Chains: make(map[string]ChainConfig),
+	}
+
+	var errs []error
+	upstreamFiles := make(map[string]string)
+	chainFiles := make(map[string]string)
+	for _, path := range paths {
+		r, err := open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := decode(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, upstream := range cfg.UpstreamConfig.Upstreams {
+			if file, ok := upstreamFiles[upstream.ID]; ok && file != path {
+				errs = append(errs, fmt.Errorf("duplicate upstream id %s in %s and %s", upstream.ID, file, path))
+			}
+			upstreamFiles[upstream.ID] = path
+		}
+		merged.UpstreamConfig.Upstreams = append(merged.UpstreamConfig.Upstreams, cfg.UpstreamConfig.Upstreams...)
+
+		for chain, chainCfg := range cfg.Chains {
+			if file, ok := chainFiles[chain]; ok {
+				errs = append(errs, fmt.Errorf("duplicate settings for chain %s in %s and %s", chain, file, path))
+			}
+			chainFiles[chain] = path
+			merged.Chains[chain] = chainCfg
+		}
+	}
+
+	for _, node := range opts.Nodes {
+		if file, ok := upstreamFiles[node.ID]; ok {
+			errs = append(errs, fmt.Errorf("duplicate upstream id %s in %s and additional nodes", node.ID, file))
+		}
+	}
+	merged.addNodes(opts.Nodes)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return merged.finish(opts)
+}
+
+// LoadFrom parses and validates a YAML config read from r
+func LoadFrom(r io.Reader, opts LoadOptions) (*Config, error) {
+	cfg, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+	cfg.addNodes(opts.Nodes)
+
+	return cfg.finish(opts)
+}
+
+// open returns a reader for the config at path, which is a file path, "-"
+// for stdin or an http(s) URL
+func open(path string) (io.Reader, error) {
+	switch {
+	case path == "-":
+		return os.Stdin, nil
+	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
+		data, err := fetch(path, configFetchTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch config: %w", err)
+		}
+		return bytes.NewReader(data), nil
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return bytes.NewReader(data), nil
+	}
+}
+
+// decode parses a YAML config without validating it
+func decode(r io.Reader) (*Config, error) {
+	// Reject unknown fields so that typos don't silently drop nodes
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Normalize the flat nodes list into upstreams
+	cfg.addNodes(cfg.Nodes)
+	cfg.Nodes = nil
+
+	return &cfg, nil
+}
+
+// addNodes adds every flat node as an upstream with a single json-rpc
+// connector
+func (c *Config) addNodes(nodes []FlatNode) {
+	for _, node := range nodes {
+		c.UpstreamConfig.Upstreams = append(c.UpstreamConfig.Upstreams, Upstream{
+			ID:    node.ID,
+			Chain: node.Chain,
+			Connectors: []Connector{{
+				Type:    checkedConnectorType,
+				URL:     node.URL,
+				Headers: node.Headers,
+			}},
+		})
+	}
+}
+
+// finish checks that upstreams are configured and validates the config
+func (c *Config) finish(opts LoadOptions) (*Config, error) {
+	if len(c.UpstreamConfig.Upstreams) == 0 {
+		return nil, fmt.Errorf("no nodes configured in config: expected upstream-config.upstreams or a top-level nodes list")
+	}
+
+	if err := c.validate(opts); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// fetch downloads the config at url. The download must finish within
+// timeout and the config must not exceed maxConfigFetchSize.
+func fetch(url string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigFetchSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxConfigFetchSize {
+		return nil, fmt.Errorf("config exceeds %d bytes", maxConfigFetchSize)
+	}
+
+	return data, nil
+}
+
+// validate expands environment variables and checks all upstreams.
+// All problems are returned together rather than failing on the first one.
+func (c *Config) validate(opts LoadOptions) error {
+	var errs []error
+
+	seen := make(map[string]bool)
+	for i := range c.UpstreamConfig.Upstreams {
+		upstream := &c.UpstreamConfig.Upstreams[i]
+
+		name := upstream.ID
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+			errs = append(errs, fmt.Errorf("upstream %s has empty id", name))
+		}
+		if upstream.Chain == "" {
+			errs = append(errs, fmt.Errorf("upstream %s has empty chain", name))
+		}
+		if err := validatePinnedHashes(upstream.PinnedHashes); err != nil {
+			errs = append(errs, fmt.Errorf("upstream %s: %w", name, err))
+		}
+
+		checked := 0
+		for j := range upstream.Connectors {
+			connector := &upstream.Connectors[j]
+
+			if connector.Type == checkedConnectorType {
+				checked++
+			}
+			if problem, strict := connectorTypeProblem(connector.Type); problem != "" {
+				if strict && opts.StrictConnectorTypes {
+					errs = append(errs, fmt.Errorf("upstream %s has %s", name, problem))
+				} else {
+					c.Warnings = append(c.Warnings, fmt.Sprintf("upstream %s: skipping %s", name, problem))
+				}
+			}
+
+			// Expand environment variables in connector URLs and headers
+			url, err := expandEnv(connector.URL)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("upstream %s: %w", name, err))
+				continue
+			}
+			connector.URL = url
+
+			for key, value := range connector.Headers {
+				expanded, err := expandEnv(value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("upstream %s header %s: %w", name, key, err))
+					continue
+				}
+				connector.Headers[key] = expanded
+			}
+
+			// Check for empty, malformed and duplicate addresses. Only
+			// json-rpc connectors are dialed.
+			if connector.URL == "" {
+				errs = append(errs, fmt.Errorf("upstream %s has empty connector URL", name))
+				continue
+			}
+			if connector.Type == checkedConnectorType {
+				if err := ValidateURL(connector.URL); err != nil {
+					errs = append(errs, fmt.Errorf("upstream %s has invalid connector URL %s: %w", name, RedactURL(connector.URL), err))
+					continue
+				}
+			}
+			if connector.Priority != "" && connector.Priority != PriorityPrimary && connector.Priority != PriorityFallback {
+				errs = append(errs, fmt.Errorf("upstream %s has connector with unknown priority %q, must be %s or %s", name, connector.Priority, PriorityPrimary, PriorityFallback))
+			}
+			if connector.TLS != nil {
+				if err := connector.TLS.expandEnv(); err != nil {
+					errs = append(errs, fmt.Errorf("upstream %s: %w", name, err))
+				} else if err := connector.TLS.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("upstream %s: %w", name, err))
+				} else if !strings.HasPrefix(connector.URL, "https://") {
+					errs = append(errs, fmt.Errorf("upstream %s: tls is only supported for https connector URLs", name))
+				}
+			}
+			if seen[connector.URL] {
+				if opts.AllowDuplicateURLs {
+					c.Warnings = append(c.Warnings, fmt.Sprintf("duplicate connector URL: %s", RedactURL(connector.URL)))
+				} else {
+					errs = append(errs, fmt.Errorf("duplicate connector URL: %s", RedactURL(connector.URL)))
+				}
+				continue
+			}
+			seen[connector.URL] = true
+		}
+
+		if checked == 0 {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("upstream %s has no %s connectors and is not checked", name, checkedConnectorType))
+		}
+	}
+
+	// Validate chain settings and expand environment variables in trusted
+	// peer URLs
+	for chain, chainCfg := range c.Chains {
+		if chainCfg.BlockHashCount != nil && *chainCfg.BlockHashCount < 0 {
+			errs = append(errs, fmt.Errorf("chain %s has negative block-hash-count", chain))
+		}
+		if chainCfg.SmokeCall != nil {
+			if err := chainCfg.SmokeCall.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("chain %s: %w", chain, err))
+			}
+		}
+		if err := validatePinnedHashes(chainCfg.PinnedHashes); err != nil {
+			errs = append(errs, fmt.Errorf("chain %s: %w", chain, err))
+		}
+
+		url, err := expandEnv(chainCfg.TrustedPeer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chain %s: %w", chain, err))
+			continue
+		}
+		if url != "" {
+			if err := ValidateURL(url); err != nil {
+				errs = append(errs, fmt.Errorf("chain %s has invalid trusted-peer URL %s: %w", chain, RedactURL(url), err))
+				continue
+			}
+		}
+		chainCfg.TrustedPeer = url
+		c.Chains[chain] = chainCfg
+	}
+
+	return errors.Join(errs...)
+}
+
+// expandEnv replaces ${VAR} and $VAR references with environment variable
+// values. Unlike os.ExpandEnv it returns an error for undefined variables.
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(key string) string {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			missing = append(missing, key)
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variable: %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// expandEnv expands environment variables in the file paths
+func (t *TLSConfig) expandEnv() error {
+	for _, path := range []*string{&t.CertFile, &t.KeyFile, &t.CAFile} {
+		expanded, err := expandEnv(*path)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		*path = expanded
+	}
+	return nil
+}
+
+// GetNodesByChain returns all nodes grouped by chain. Only json-rpc
+// connectors are returned, Load warns about the skipped ones.
+func (c *Config) GetNodesByChain() map[string][]NodeInfo {
+	result := make(map[string][]NodeInfo)
+
+	for _, upstream := range c.UpstreamConfig.Upstreams {
+		for _, connector := range upstream.Connectors {
+			if connector.Type != checkedConnectorType {
+				continue
+			}
+			result[upstream.Chain] = append(result[upstream.Chain], c.newNodeInfo(upstream, connector))
+		}
+	}
+
+	return result
+}
+
+// FilterChains keeps only upstreams and chain settings of the given chains.
+// It returns an error listing the available chains if a requested chain is
+// not configured.
+func (c *Config) FilterChains(chains []string) error {
+	available := make(map[string]bool)
+	for _, upstream := range c.UpstreamConfig.Upstreams {
+		available[upstream.Chain] = true
+	}
+
+	for _, chain := range chains {
+		if !available[chain] {
+			names := make([]string, 0, len(available))
+			for name := range available {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return fmt.Errorf("chain %s not found in config, available chains: %s", chain, strings.Join(names, ", "))
+		}
+	}
+
+	filtered := make([]Upstream, 0, len(c.UpstreamConfig.Upstreams))
+	for _, upstream := range c.UpstreamConfig.Upstreams {
+		if slices.Contains(chains, upstream.Chain) {
+			filtered = append(filtered, upstream)
+		}
+	}
+	c.UpstreamConfig.Upstreams = filtered
+
+	// Settings of the other chains are unused, not a problem to report
+	maps.DeleteFunc(c.Chains, func(chain string, _ ChainConfig) bool {
+		return !slices.Contains(chains, chain)
+	})
+
+	return nil
+}
+
+// Lint reports problems that don't prevent the config from loading but leave
+// chain settings unused. Connectors that are not checked are reported by
+// Load in Warnings, or as errors with LoadOptions.StrictConnectorTypes.
+func (c *Config) Lint() []string {
+	var problems []string
+
+	chains := make(map[string]bool)
+	for _, upstream := range c.UpstreamConfig.Upstreams {
+		chains[upstream.Chain] = true
+	}
+
+	for _, chain := range slices.Sorted(maps.Keys(c.Chains)) {
+		if !chains[chain] {
+			problems = append(problems, fmt.Sprintf("chain %s in chains section has no upstreams", chain))
+		}
+	}
+
+	return problems
+}
+
+// GetAllNodes returns all nodes as a flat list
+func (c *Config) GetAllNodes() []NodeInfo {
+	var result []NodeInfo
+
+	for _, upstream := range c.UpstreamConfig.Upstreams {
+		for _, connector := range upstream.Connectors {
+			if connector.Type != checkedConnectorType {
+				continue
+			}
+			result = append(result, c.newNodeInfo(upstream, connector))
+		}
+	}
+
+	return result
+}
+
+// newNodeInfo builds a NodeInfo from an upstream connector. Connector
+// settings take precedence over upstream settings.
+func (c *Config) newNodeInfo(upstream Upstream, connector Connector) NodeInfo {
+	node := NodeInfo{
+		ID:               upstream.ID,
+		Chain:            upstream.Chain,
+		Address:          connector.URL,
+		Headers:          connector.Headers,
+		MaxBlockGap:      upstream.MaxBlockGap,
+		TLS:              connector.TLS,
+		Trusted:          upstream.Trusted || connector.Trusted,
+		DebugUnsupported: upstream.DebugUnsupported || c.Chains[upstream.Chain].DebugUnsupported,
+		AcceptedChainIDs: upstream.AcceptedChainIDs,
+		Priority:         connector.Priority,
+		PinnedHashes:     mergePinnedHashes(c.Chains[upstream.Chain].PinnedHashes, upstream.PinnedHashes),
+	}
+
+	if connector.MaxBlockGap != nil {
+		node.MaxBlockGap = connector.MaxBlockGap
+	}
+
+	return node
+}
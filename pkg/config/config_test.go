@@ -0,0 +1,837 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load parses and validates a YAML config with default options
+func load(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	return LoadFrom(strings.NewReader(yaml), LoadOptions{})
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv("RPC_KEY", "secret")
+	t.Setenv("RPC_HOST", "eth.example.com")
+
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://$RPC_HOST/v2/${RPC_KEY}
+          headers:
+            x-api-key: ${RPC_KEY}
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	connector := cfg.UpstreamConfig.Upstreams[0].Connectors[0]
+	if want := "https://eth.example.com/v2/secret"; connector.URL != want {
+		t.Errorf("URL = %q, want %q", connector.URL, want)
+	}
+	if got := connector.Headers["x-api-key"]; got != "secret" {
+		t.Errorf("header = %q, want %q", got, "secret")
+	}
+}
+
+func TestLoadUndefinedEnv(t *testing.T) {
+	_, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com/v2/${EVM_NODE_CHECK_UNSET_KEY}
+`)
+	if err == nil {
+		t.Fatal("load succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "undefined environment variable: EVM_NODE_CHECK_UNSET_KEY") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExpandEnvEmptyValue(t *testing.T) {
+	// Variables that are set but empty are not undefined
+	t.Setenv("RPC_KEY", "")
+
+	got, err := expandEnv("https://eth.example.com/${RPC_KEY}")
+	if err != nil {
+		t.Fatalf("expandEnv: %v", err)
+	}
+	if want := "https://eth.example.com/"; got != want {
+		t.Errorf("expandEnv = %q, want %q", got, want)
+	}
+}
+
+func TestLoadHeaders(t *testing.T) {
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: provider
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+          headers:
+            Authorization: Bearer token
+            x-api-key: key
+nodes:
+  - id: flat
+    chain: ethereum
+    url: https://flat.example.com
+    headers:
+      x-api-key: flat-key
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	nodes := cfg.GetNodesByChain()["ethereum"]
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(nodes))
+	}
+
+	headers := map[string]map[string]string{}
+	for _, node := range nodes {
+		headers[node.ID] = node.Headers
+	}
+	if got := headers["provider"]; got["Authorization"] != "Bearer token" || got["x-api-key"] != "key" {
+		t.Errorf("provider headers = %v", got)
+	}
+	if got := headers["flat"]; got["x-api-key"] != "flat-key" {
+		t.Errorf("flat node headers = %v", got)
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	_, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      conectors:
+        - type: json-rpc
+          url: https://eth.example.com
+`)
+	if err == nil {
+		t.Fatal("load succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "field conectors not found") {
+		t.Errorf("error doesn't name the misspelled key: %v", err)
+	}
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	_, err := load(t, `
+upstream-config:
+  upstreams:
+    - chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://a.example.com
+    - id: no-chain
+      connectors:
+        - type: json-rpc
+          url: https://b.example.com
+    - id: unset
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://c.example.com/${EVM_NODE_CHECK_UNSET_KEY}
+`)
+	if err == nil {
+		t.Fatal("load succeeded, want error")
+	}
+
+	for _, want := range []string{
+		"upstream #1 has empty id",
+		"upstream no-chain has empty chain",
+		"upstream unset: undefined environment variable: EVM_NODE_CHECK_UNSET_KEY",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't contain %q: %v", want, err)
+		}
+	}
+}
+
+func TestConnectorTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      string
+		strict   bool
+		wantErr  bool
+		warnings int
+		warning  string
+	}{
+		{name: "json-rpc", typ: "json-rpc"},
+		{name: "ws", typ: "ws", warnings: 2, warning: "upstream node: skipping ws connector, only json-rpc connectors are checked"},
+		{name: "ipc", typ: "ipc", warnings: 2},
+		{name: "ws strict", typ: "ws", strict: true, warnings: 2},
+		{name: "unknown", typ: "jsonrpc", warnings: 2, warning: `upstream node: skipping connector with unknown type "jsonrpc"`},
+		{name: "unknown strict", typ: "jsonrpc", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yaml := `
+upstream-config:
+  upstreams:
+    - id: node
+      chain: ethereum
+      connectors:
+        - type: ` + tt.typ + `
+          url: https://eth.example.com
+`
+			cfg, err := LoadFrom(strings.NewReader(yaml), LoadOptions{StrictConnectorTypes: tt.strict})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(cfg.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", cfg.Warnings, tt.warnings)
+			}
+			if tt.warning != "" && !slices.Contains(cfg.Warnings, tt.warning) {
+				t.Errorf("warnings = %q, want %q", cfg.Warnings, tt.warning)
+			}
+			if tt.warnings > 0 && !slices.Contains(cfg.Warnings, "upstream node has no json-rpc connectors and is not checked") {
+				t.Errorf("warnings = %q, want the upstream to be reported as not checked", cfg.Warnings)
+			}
+
+			// Lint never rejects what Load accepts
+			if problems := cfg.Lint(); len(problems) > 0 {
+				t.Errorf("Lint = %q, want no problems", problems)
+			}
+		})
+	}
+}
+
+func TestLintUnusedChainSettings(t *testing.T) {
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: node
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+chains:
+  ethereum:
+    max-block-gap: 5
+  polygon:
+    max-block-gap: 50
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	problems := cfg.Lint()
+	if len(problems) != 1 || problems[0] != "chain polygon in chains section has no upstreams" {
+		t.Errorf("Lint = %q", problems)
+	}
+}
+
+func TestFilterChainsExampleConfig(t *testing.T) {
+	cfg, err := Load(filepath.Join("..", "..", "config.example.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if problems := cfg.Lint(); len(problems) > 0 {
+		t.Fatalf("Lint of the example config = %q, want none", problems)
+	}
+
+	if err := cfg.FilterChains([]string{"sepolia"}); err != nil {
+		t.Fatalf("FilterChains: %v", err)
+	}
+	if problems := cfg.Lint(); len(problems) > 0 {
+		t.Errorf("Lint after filtering = %q, want none", problems)
+	}
+	if chains := slices.Collect(maps.Keys(cfg.GetNodesByChain())); !slices.Equal(chains, []string{"sepolia"}) {
+		t.Errorf("chains = %v, want sepolia", chains)
+	}
+	for chain := range cfg.Chains {
+		if chain != "sepolia" {
+			t.Errorf("settings of chain %s kept after filtering", chain)
+		}
+	}
+}
+
+const validConfig = `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+`
+
+// invalidConfig fails validation, not parsing
+const invalidConfig = `
+upstream-config:
+  upstreams:
+    - id: infura
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+`
+
+func TestLoadSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/valid.yaml":
+			io.WriteString(w, validConfig)
+		case "/invalid.yaml":
+			io.WriteString(w, invalidConfig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"valid.yaml": validConfig, "invalid.yaml": invalidConfig} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := map[string]func(t *testing.T, content, name string) (*Config, error){
+		"reader": func(t *testing.T, content, name string) (*Config, error) {
+			return LoadFrom(strings.NewReader(content), LoadOptions{})
+		},
+		"file": func(t *testing.T, content, name string) (*Config, error) {
+			return Load(filepath.Join(dir, name), LoadOptions{})
+		},
+		"stdin": func(t *testing.T, content, name string) (*Config, error) {
+			stdin, err := os.Open(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stdin.Close()
+
+			orig := os.Stdin
+			os.Stdin = stdin
+			defer func() { os.Stdin = orig }()
+
+			return Load("-", LoadOptions{})
+		},
+		"url": func(t *testing.T, content, name string) (*Config, error) {
+			return Load(server.URL+"/"+name, LoadOptions{})
+		},
+	}
+
+	// Every source shares the same validation
+	for source, load := range sources {
+		t.Run(source, func(t *testing.T) {
+			cfg, err := load(t, validConfig, "valid.yaml")
+			if err != nil {
+				t.Fatalf("load valid config: %v", err)
+			}
+			if nodes := cfg.GetAllNodes(); len(nodes) != 1 || nodes[0].ID != "infura" {
+				t.Errorf("nodes = %+v", nodes)
+			}
+
+			_, err = load(t, invalidConfig, "invalid.yaml")
+			if err == nil || !strings.Contains(err.Error(), "upstream infura has empty chain") {
+				t.Errorf("load invalid config: error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadURLStatus(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := Load(server.URL+"/config.yaml", LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unexpected status: 404") {
+		t.Errorf("error = %v, want unexpected status", err)
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	if _, err := load(t, ""); err == nil || !strings.Contains(err.Error(), "no nodes configured") {
+		t.Errorf("error = %v, want no nodes configured", err)
+	}
+}
+
+// writeConfigs writes each config to a file in a temporary directory and
+// returns the paths in order
+func writeConfigs(t *testing.T, configs ...string) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, len(configs))
+	for i, content := range configs {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("config-%d.yaml", i+1))
+		if err := os.WriteFile(paths[i], []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestLoadFilesMerge(t *testing.T) {
+	paths := writeConfigs(t, `
+upstream-config:
+  upstreams:
+    - id: eth-a
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth-a.example.com
+chains:
+  ethereum:
+    max-block-gap: 5
+`, `
+nodes:
+  - id: polygon-a
+    chain: polygon
+    url: https://polygon-a.example.com
+chains:
+  polygon:
+    max-block-gap: 50
+`)
+
+	cfg, err := LoadFiles(paths, LoadOptions{
+		Nodes: []FlatNode{{ID: "eth-b", Chain: "ethereum", URL: "https://eth-b.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+
+	var ids []string
+	for _, node := range cfg.GetAllNodes() {
+		ids = append(ids, node.ID)
+	}
+	if want := []string{"eth-a", "polygon-a", "eth-b"}; !slices.Equal(ids, want) {
+		t.Errorf("nodes = %v, want %v", ids, want)
+	}
+
+	for chain, want := range map[string]uint64{"ethereum": 5, "polygon": 50} {
+		if gap := cfg.Chains[chain].MaxBlockGap; gap == nil || *gap != want {
+			t.Errorf("chain %s max-block-gap = %v, want %d", chain, gap, want)
+		}
+	}
+}
+
+func TestLoadFilesDuplicates(t *testing.T) {
+	first := `
+upstream-config:
+  upstreams:
+    - id: eth-a
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth-a.example.com
+chains:
+  ethereum:
+    max-block-gap: 5
+`
+	second := `
+nodes:
+  - id: eth-a
+    chain: ethereum
+    url: https://eth-other.example.com
+chains:
+  ethereum:
+    max-block-gap: 10
+`
+	paths := writeConfigs(t, first, second)
+
+	_, err := LoadFiles(paths, LoadOptions{
+		Nodes: []FlatNode{{ID: "eth-a", Chain: "ethereum", URL: "https://eth-extra.example.com"}},
+	})
+	if err == nil {
+		t.Fatal("LoadFiles succeeded, want error")
+	}
+
+	for _, want := range []string{
+		fmt.Sprintf("duplicate upstream id eth-a in %s and %s", paths[0], paths[1]),
+		fmt.Sprintf("duplicate settings for chain ethereum in %s and %s", paths[0], paths[1]),
+		"duplicate upstream id eth-a in " + paths[1] + " and additional nodes",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error doesn't contain %q: %v", want, err)
+		}
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	upstream := `
+upstream-config:
+  upstreams:
+    - id: a
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://a.example.com
+    - id: b
+      chain: polygon
+      connectors:
+        - type: json-rpc
+          url: https://b.example.com
+`
+	flat := `
+nodes:
+  - id: a
+    chain: ethereum
+    url: https://a.example.com
+  - id: b
+    chain: polygon
+    url: https://b.example.com
+`
+	mixed := `
+upstream-config:
+  upstreams:
+    - id: a
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://a.example.com
+nodes:
+  - id: b
+    chain: polygon
+    url: https://b.example.com
+`
+
+	var want []NodeInfo
+	for name, yaml := range map[string]string{"upstream": upstream, "flat": flat, "mixed": mixed} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := load(t, yaml)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			nodes := cfg.GetAllNodes()
+			if len(nodes) != 2 || nodes[0].ID != "a" || nodes[0].Chain != "ethereum" || nodes[0].Address != "https://a.example.com" ||
+				nodes[1].ID != "b" || nodes[1].Chain != "polygon" || nodes[1].Address != "https://b.example.com" {
+				t.Errorf("nodes = %+v", nodes)
+			}
+
+			// All schemas normalize to the same nodes
+			if want == nil {
+				want = nodes
+			} else if !reflect.DeepEqual(nodes, want) {
+				t.Errorf("nodes = %+v, want %+v", nodes, want)
+			}
+		})
+	}
+}
+
+func TestLoadUnrecognizedSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "empty", yaml: "", want: "no nodes configured in config: expected upstream-config.upstreams or a top-level nodes list"},
+		{name: "chain settings only", yaml: "chains:\n  ethereum:\n    max-block-gap: 5\n", want: "no nodes configured in config"},
+		{name: "empty lists", yaml: "upstream-config:\n  upstreams: []\nnodes: []\n", want: "no nodes configured in config"},
+		{name: "unknown schema", yaml: "servers:\n  - host: https://a.example.com\n", want: "field servers not found"},
+		{name: "top-level list", yaml: "- id: a\n  url: https://a.example.com\n", want: "failed to parse config"},
+		{name: "flat node without url", yaml: "nodes:\n  - id: a\n    chain: ethereum\n", want: "upstream a has empty connector URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("load error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow.yaml":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/max.yaml":
+			w.Write(bytes.Repeat([]byte("#"), maxConfigFetchSize))
+		case "/oversize.yaml":
+			w.Write(bytes.Repeat([]byte("#"), maxConfigFetchSize+1))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := fetch(server.URL+"/slow.yaml", 100*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow config: error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("slow config: gave up after %s, want 100ms", elapsed)
+	}
+
+	if data, err := fetch(server.URL+"/max.yaml", time.Minute); err != nil || len(data) != maxConfigFetchSize {
+		t.Errorf("config of max size: got %d bytes, error %v", len(data), err)
+	}
+
+	_, err = fetch(server.URL+"/oversize.yaml", time.Minute)
+	if err == nil || err.Error() != fmt.Sprintf("config exceeds %d bytes", maxConfigFetchSize) {
+		t.Errorf("oversize config: error = %v, want size error", err)
+	}
+
+	// Load names the failed fetch
+	_, err = Load(server.URL+"/oversize.yaml", LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch config: config exceeds") {
+		t.Errorf("Load of oversize config: error = %v", err)
+	}
+}
+
+func TestLoadURLInvalidYAML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><body>Not Found</body></html>")
+	}))
+	defer server.Close()
+
+	_, err := Load(server.URL+"/config.yaml", LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse config") {
+		t.Errorf("error = %v, want parse error", err)
+	}
+}
+
+func TestValidatePinnedHashes(t *testing.T) {
+	const hash = "0x56a9bb0302da44b8c0b3df540781424684c3af04d0b7a38d72842b762076a664"
+
+	tests := []struct {
+		name    string
+		pins    []PinnedHash
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "valid", pins: []PinnedHash{{Block: 0, Hash: hash}, {Block: 15537394, Hash: hash}}},
+		{name: "upper case", pins: []PinnedHash{{Block: 1, Hash: "0x" + strings.ToUpper(hash[2:])}}},
+		{name: "empty", pins: []PinnedHash{{Block: 1}}, wantErr: `pinned hash of block 1 must be a 32 byte hex hash, got ""`},
+		{name: "no prefix", pins: []PinnedHash{{Block: 1, Hash: hash[2:]}}, wantErr: "pinned hash of block 1 must be a 32 byte hex hash"},
+		{name: "short", pins: []PinnedHash{{Block: 1, Hash: hash[:64]}}, wantErr: "pinned hash of block 1 must be a 32 byte hex hash"},
+		{name: "long", pins: []PinnedHash{{Block: 1, Hash: hash + "00"}}, wantErr: "pinned hash of block 1 must be a 32 byte hex hash"},
+		{name: "not hex", pins: []PinnedHash{{Block: 1, Hash: hash[:65] + "g"}}, wantErr: "pinned hash of block 1 must be a 32 byte hex hash"},
+		{name: "pinned twice", pins: []PinnedHash{{Block: 1, Hash: hash}, {Block: 1, Hash: hash}}, wantErr: "block 1 is pinned twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePinnedHashes(tt.pins)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePinnedHashes = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePinnedHashes = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPinnedHashes(t *testing.T) {
+	const (
+		chainHash    = "0x1111111111111111111111111111111111111111111111111111111111111111"
+		upstreamHash = "0x2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      pinned-hashes:
+        - block: 200
+          hash: "`+upstreamHash+`"
+        - block: 50
+          hash: "`+upstreamHash+`"
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+chains:
+  ethereum:
+    pinned-hashes:
+      - block: 100
+        hash: "`+chainHash+`"
+      - block: 200
+        hash: "`+chainHash+`"
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Pins of the upstream replace those of the chain at the same block
+	want := []PinnedHash{{Block: 50, Hash: upstreamHash}, {Block: 100, Hash: chainHash}, {Block: 200, Hash: upstreamHash}}
+	nodes := cfg.GetNodesByChain()["ethereum"]
+	if len(nodes) != 1 || !slices.Equal(nodes[0].PinnedHashes, want) {
+		t.Errorf("nodes = %+v, want pinned hashes %v", nodes, want)
+	}
+}
+
+func TestLoadInvalidPinnedHashes(t *testing.T) {
+	const hash = "0x56a9bb0302da44b8c0b3df540781424684c3af04d0b7a38d72842b762076a664"
+
+	tests := []struct {
+		name    string
+		pin     string
+		wantErr string
+	}{
+		{name: "invalid hash", pin: `{block: 1, hash: "0x1234"}`, wantErr: `chain ethereum: pinned hash of block 1 must be a 32 byte hex hash, got "0x1234"`},
+		{name: "negative number", pin: `{block: -1, hash: "` + hash + `"}`, wantErr: "cannot unmarshal"},
+		{name: "not a number", pin: `{block: latest, hash: "` + hash + `"}`, wantErr: `pinned block must be a block number, got "latest"`},
+		{name: "fraction", pin: `{block: 1.5, hash: "` + hash + `"}`, wantErr: `pinned block must be a block number, got "1.5"`},
+		{name: "quoted number", pin: `{block: "1", hash: "` + hash + `"}`, wantErr: `pinned block must be a block number, got "1"`},
+		{name: "unknown field", pin: `{block: 1, hsah: "` + hash + `"}`, wantErr: "field hsah not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, validConfig+`
+chains:
+  ethereum:
+    pinned-hashes:
+      - `+tt.pin+`
+`)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("load = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	_, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      pinned-hashes:
+        - {block: 1, hash: "`+hash+`"}
+        - {block: 1, hash: "`+hash+`"}
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+`)
+	if err == nil || !strings.Contains(err.Error(), "upstream infura: block 1 is pinned twice") {
+		t.Errorf("load = %v, want block pinned twice", err)
+	}
+}
+
+func TestLoadConnectorPriority(t *testing.T) {
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://primary.example.com
+          priority: primary
+        - type: json-rpc
+          url: https://fallback.example.com
+          priority: fallback
+        - type: json-rpc
+          url: https://plain.example.com
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	want := map[string]string{
+		"https://primary.example.com":  PriorityPrimary,
+		"https://fallback.example.com": PriorityFallback,
+		"https://plain.example.com":    "",
+	}
+	nodes := cfg.GetNodesByChain()["ethereum"]
+	if len(nodes) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(nodes), len(want))
+	}
+	for _, node := range nodes {
+		if node.Priority != want[node.Address] {
+			t.Errorf("priority of %s = %q, want %q", node.Address, node.Priority, want[node.Address])
+		}
+	}
+
+	_, err = load(t, `
+upstream-config:
+  upstreams:
+    - id: infura
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://eth.example.com
+          priority: backup
+`)
+	if err == nil || !strings.Contains(err.Error(), `upstream infura has connector with unknown priority "backup"`) {
+		t.Errorf("load = %v, want unknown priority", err)
+	}
+}
+
+func TestLoadDebugUnsupported(t *testing.T) {
+	cfg, err := load(t, `
+upstream-config:
+  upstreams:
+    - id: erigon
+      chain: ethereum
+      connectors:
+        - type: json-rpc
+          url: https://erigon.example.com
+    - id: light
+      chain: ethereum
+      debug-unsupported: true
+      connectors:
+        - type: json-rpc
+          url: https://light.example.com
+    - id: zksync
+      chain: zksync
+      connectors:
+        - type: json-rpc
+          url: https://zksync.example.com
+chains:
+  zksync:
+    debug-unsupported: true
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	want := map[string]bool{
+		"https://erigon.example.com": false,
+		"https://light.example.com":  true,
+		"https://zksync.example.com": true,
+	}
+	nodes := cfg.GetAllNodes()
+	if len(nodes) != len(want) {
+		t.Fatalf("got %d nodes, want %d", len(nodes), len(want))
+	}
+	for _, node := range nodes {
+		if node.DebugUnsupported != want[node.Address] {
+			t.Errorf("debug unsupported of %s = %t, want %t", node.Address, node.DebugUnsupported, want[node.Address])
+		}
+	}
+}
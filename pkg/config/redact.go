@@ -0,0 +1,107 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redactedValue replaces secrets in redacted URLs
+const redactedValue = "xxxxx"
+
+// minPathKeyLength is the minimum length of path segments taken for API keys
+const minPathKeyLength = 20
+
+// RedactURL masks the password (or the user name if there is no password),
+// path segments that look like API keys and all query parameter values of a
+// URL so that it can be logged or serialized.
+// Unparsable URLs are fully masked.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+
+	// A user without a password is often an API key, e.g.
+	// https://<key>@eth.example.com
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+		} else {
+			u.User = url.User(redactedValue)
+		}
+	}
+
+	// Providers like Alchemy and Infura put the key in the path, e.g.
+	// https://eth-mainnet.g.alchemy.com/v2/<key>
+	if u.Path != "" {
+		segments := strings.Split(u.Path, "/")
+		masked := false
+		for i, segment := range segments {
+			if isPathKey(segment) {
+				segments[i] = redactedValue
+				masked = true
+			}
+		}
+		if masked {
+			u.Path = strings.Join(segments, "/")
+			u.RawPath = ""
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{redactedValue}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// isPathKey reports whether a path segment looks like an API key: a long
+// token of letters, digits, '-' and '_' that has a digit or no '-'. Long
+// hyphenated words like network names are kept.
+func isPathKey(segment string) bool {
+	if len(segment) < minPathKeyLength {
+		return false
+	}
+
+	digit := false
+	for _, r := range segment {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+
+	return digit || !strings.Contains(segment, "-")
+}
+
+// RedactSecrets replaces every occurrence of raw in s with its redacted form.
+// Besides raw itself it also matches the forms net/http puts in its errors.
+func RedactSecrets(s, raw string) string {
+	redacted := RedactURL(raw)
+	if raw == "" || redacted == raw {
+		return s
+	}
+
+	forms := []string{raw}
+	if u, err := url.Parse(raw); err == nil {
+		forms = append(forms, u.String())
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				forms = append(forms, strings.Replace(u.String(), u.User.String()+"@", u.User.Username()+":***@", 1))
+			}
+		}
+	}
+
+	for _, form := range forms {
+		s = strings.ReplaceAll(s, form, redacted)
+	}
+
+	return s
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadChainRegistry reads a chains registry file mapping chain names to their
+// expected chain IDs:
+//
+//	my-devnet: 1337
+//	ethereum: 1
+//
+// Names are matched case-insensitively.
+func LoadChainRegistry(path string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chains registry: %w", err)
+	}
+
+	var raw map[string]uint64
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse chains registry: %w", err)
+	}
+
+	registry := make(map[string]uint64, len(raw))
+	for name, chainID := range raw {
+		registry[strings.ToLower(name)] = chainID
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// supportedSchemes are the URL schemes of nodes the checker can dial
+var supportedSchemes = []string{"http", "https", "ws", "wss"}
+
+// ValidateURL checks that raw is a node URL the checker can dial: an http(s)
+// or ws(s) URL with a host and, if set, a port between 1 and 65535. IPv6
+// hosts must be in brackets, e.g. http://[2001:db8::1]:8545. Errors don't
+// contain raw since it may hold secrets.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Error repeats the URL, keep only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+
+	// Without "//", "localhost:8545" parses with the scheme "localhost"
+	if u.Scheme == "" || u.Opaque != "" {
+		return fmt.Errorf("missing scheme, expected one of %s", strings.Join(supportedSchemes, ", "))
+	}
+	if !slices.Contains(supportedSchemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("unsupported scheme %q, expected one of %s", u.Scheme, strings.Join(supportedSchemes, ", "))
+	}
+	if u.Hostname() == "" {
+		return errors.New("missing host")
+	}
+	if strings.Contains(u.Hostname(), ":") && !strings.HasPrefix(u.Host, "[") {
+		return errors.New("IPv6 addresses must be in brackets, e.g. http://[::1]:8545")
+	}
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+
+	return nil
+}